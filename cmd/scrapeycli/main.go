@@ -1,11 +1,19 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/fatih/color"
 	"github.com/heinrichb/scrapey-cli/pkg/config"
+	"github.com/heinrichb/scrapey-cli/pkg/crawler"
+	"github.com/heinrichb/scrapey-cli/pkg/parser"
+	"github.com/heinrichb/scrapey-cli/pkg/storage"
 	"github.com/heinrichb/scrapey-cli/pkg/utils"
 )
 
@@ -16,14 +24,22 @@ Global variables for storing command-line arguments.
 - url: The URL to be scraped, which may override the URL in the config.
 - maxDepth: Overrides the scraping depth if set.
 - rateLimit: Overrides the request rate limit.
+- concurrency: Overrides the number of crawl workers.
+- perHostConcurrency: Overrides the number of simultaneous requests per host.
+- ignoreRobots: Disables robots.txt enforcement (for sites we own).
+- maxPages: Overrides the number of pages followed per pagination chain.
 - verbose: Enables verbose output.
 */
 var (
-	configPath string
-	url        string
-	maxDepth   int
-	rateLimit  float64
-	verbose    bool
+	configPath         string
+	url                string
+	maxDepth           int
+	rateLimit          float64
+	concurrency        int
+	perHostConcurrency int
+	ignoreRobots       bool
+	maxPages           int
+	verbose            bool
 )
 
 /*
@@ -34,6 +50,9 @@ It sets up flags for:
 - URL override.
 - Scraping depth override.
 - Rate limit override.
+- Concurrency overrides (total workers and per-host cap).
+- Ignoring robots.txt ("ignore-robots").
+- Pagination page limit ("maxPages").
 - Verbose output ("verbose" and its shorthand "v").
 */
 func init() {
@@ -42,6 +61,10 @@ func init() {
 	flag.StringVar(&url, "url", "", "URL to scrape (overrides config)")
 	flag.IntVar(&maxDepth, "maxDepth", 0, "Override max crawl depth")
 	flag.Float64Var(&rateLimit, "rateLimit", 0, "Override request rate limit (seconds)")
+	flag.IntVar(&concurrency, "concurrency", 0, "Override number of concurrent crawl workers")
+	flag.IntVar(&perHostConcurrency, "perHostConcurrency", 0, "Override max concurrent requests per host")
+	flag.BoolVar(&ignoreRobots, "ignore-robots", false, "Ignore robots.txt rules (only for sites you own)")
+	flag.IntVar(&maxPages, "maxPages", 0, "Override max pages followed per pagination chain")
 	flag.BoolVar(&verbose, "verbose", false, "Enable verbose output")
 	flag.BoolVar(&verbose, "v", false, "Enable verbose output (shorthand)")
 }
@@ -50,12 +73,45 @@ func init() {
 func ptrString(s string) *string    { return &s }
 func ptrInt(i int) *int             { return &i }
 func ptrFloat64(f float64) *float64 { return &f }
+func ptrBool(b bool) *bool          { return &b }
+
+// recordSink is an output written record by record while the crawl runs. Close saves
+// it; Discard abandons it when the crawl fails.
+type recordSink interface {
+	Write(record *parser.Record) error
+	Close() error
+	Discard()
+	Path() string
+}
+
+// ensureScrapingOptions allocates overrides.ScrapingOptions on first use so
+// individual flags can set fields on it.
+func ensureScrapingOptions(overrides *config.ConfigOverride) {
+	if overrides.ScrapingOptions == nil {
+		overrides.ScrapingOptions = &struct {
+			MaxDepth           *int     `json:"maxDepth"`
+			RateLimit          *float64 `json:"rateLimit"`
+			RetryAttempts      *int     `json:"retryAttempts"`
+			UserAgent          *string  `json:"userAgent"`
+			Burst              *int     `json:"burst"`
+			Concurrency        *int     `json:"concurrency"`
+			PerHostConcurrency *int     `json:"perHostConcurrency"`
+			IgnoreRobots       *bool    `json:"ignoreRobots"`
+			Pagination         *struct {
+				NextSelector *string `json:"nextSelector"`
+				URLTemplate  *string `json:"urlTemplate"`
+				MaxPages     *int    `json:"maxPages"`
+			} `json:"pagination"`
+		}{}
+	}
+}
 
 /*
 main is the entry point of Scrapey CLI.
 
 It parses command-line flags, prints a welcome message, loads the configuration,
-applies CLI overrides using a ConfigOverride object, and prints confirmation messages.
+applies CLI overrides using a ConfigOverride object, prints confirmation messages,
+crawls the configured site, and saves the parsed and post-processed results.
 */
 func main() {
 	// Parse CLI flags.
@@ -89,6 +145,11 @@ func main() {
 			Base        *string   `json:"base"`
 			Routes      *[]string `json:"routes"`
 			IncludeBase *bool     `json:"includeBase"`
+			Sitemaps    *struct {
+				Enabled      *bool     `json:"enabled"`
+				URLs         *[]string `json:"urls"`
+				LastmodAfter *string   `json:"lastmodAfter"`
+			} `json:"sitemaps"`
 		}{
 			Base: ptrString(url),
 		}
@@ -96,30 +157,44 @@ func main() {
 
 	// Apply maxDepth override if provided.
 	if maxDepth > 0 {
-		if cliOverrides.ScrapingOptions == nil {
-			cliOverrides.ScrapingOptions = &struct {
-				MaxDepth      *int     `json:"maxDepth"`
-				RateLimit     *float64 `json:"rateLimit"`
-				RetryAttempts *int     `json:"retryAttempts"`
-				UserAgent     *string  `json:"userAgent"`
-			}{}
-		}
+		ensureScrapingOptions(&cliOverrides)
 		cliOverrides.ScrapingOptions.MaxDepth = ptrInt(maxDepth)
 	}
 
 	// Apply rateLimit override if provided.
 	if rateLimit > 0 {
-		if cliOverrides.ScrapingOptions == nil {
-			cliOverrides.ScrapingOptions = &struct {
-				MaxDepth      *int     `json:"maxDepth"`
-				RateLimit     *float64 `json:"rateLimit"`
-				RetryAttempts *int     `json:"retryAttempts"`
-				UserAgent     *string  `json:"userAgent"`
-			}{}
-		}
+		ensureScrapingOptions(&cliOverrides)
 		cliOverrides.ScrapingOptions.RateLimit = ptrFloat64(rateLimit)
 	}
 
+	// Apply concurrency overrides if provided.
+	if concurrency > 0 {
+		ensureScrapingOptions(&cliOverrides)
+		cliOverrides.ScrapingOptions.Concurrency = ptrInt(concurrency)
+	}
+	if perHostConcurrency > 0 {
+		ensureScrapingOptions(&cliOverrides)
+		cliOverrides.ScrapingOptions.PerHostConcurrency = ptrInt(perHostConcurrency)
+	}
+
+	// Apply ignore-robots override if provided.
+	if ignoreRobots {
+		ensureScrapingOptions(&cliOverrides)
+		cliOverrides.ScrapingOptions.IgnoreRobots = ptrBool(true)
+	}
+
+	// Apply maxPages override if provided.
+	if maxPages > 0 {
+		ensureScrapingOptions(&cliOverrides)
+		cliOverrides.ScrapingOptions.Pagination = &struct {
+			NextSelector *string `json:"nextSelector"`
+			URLTemplate  *string `json:"urlTemplate"`
+			MaxPages     *int    `json:"maxPages"`
+		}{
+			MaxPages: ptrInt(maxPages),
+		}
+	}
+
 	// Apply all CLI overrides dynamically.
 	cfg.OverrideConfig(cliOverrides)
 
@@ -132,6 +207,145 @@ func main() {
 		utils.PrintColored("Including base URL in scraping.", "", color.FgGreen)
 	}
 	for _, route := range cfg.URL.Routes {
-		utils.PrintColored("Scraping route: ", route, color.FgHiBlue)
+		if crawler.IsRoutePattern(route) {
+			utils.PrintColored("Discovering routes matching: ", route, color.FgHiBlue)
+		} else {
+			utils.PrintColored("Scraping route: ", route, color.FgHiBlue)
+		}
+	}
+
+	// Cancel the crawl on Ctrl-C or SIGTERM. In-flight requests are drained and
+	// whatever was scraped before the interruption is still saved.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Compile the post-processing chains (DataFormatting plus per-field transforms).
+	postProcessor, err := parser.NewPostProcessor(cfg)
+	if err != nil {
+		utils.PrintColored("Invalid transforms: ", err.Error(), color.FgRed)
+		os.Exit(1)
+	}
+
+	// Resolve the output formats before crawling. JSONL and XML output are streamed
+	// while the crawl runs, so partial JSONL results survive a crash and neither needs
+	// the records kept in memory.
+	var options []storage.StorageOption
+	for _, format := range cfg.Storage.OutputFormats {
+		option, err := storage.ParseStorageOption(format)
+		if err != nil {
+			utils.PrintColored("Skipping output format: ", err.Error(), color.FgYellow)
+			continue
+		}
+		options = append(options, option)
+	}
+	var sinks []recordSink
+	streamed := make(map[storage.StorageOption]bool)
+	collect := false
+	for _, option := range options {
+		if streamed[option] {
+			continue
+		}
+		var sink recordSink
+		switch option {
+		case storage.JSONL:
+			sink, err = storage.OpenJSONL(cfg)
+		case storage.XML:
+			sink, err = storage.OpenXML(cfg)
+		default:
+			collect = true
+			continue
+		}
+		if err != nil {
+			utils.PrintColored("Failed to save data: ", err.Error(), color.FgRed)
+			os.Exit(1)
+		}
+		streamed[option] = true
+		sinks = append(sinks, sink)
+		utils.PrintColored("Streaming results to: ", sink.Path(), color.FgGreen)
+	}
+	broken := make([]bool, len(sinks))
+	failed := false
+
+	// Crawl the site, parsing and cleaning each fetched page.
+	// parsed lists every parsed page, including those that yielded no records.
+	var results []*parser.Record
+	var parsed []string
+	c := crawler.New(cfg)
+	err = c.Crawl(ctx, func(page crawler.Page) {
+		if page.Err != nil {
+			utils.PrintColored("Failed to fetch: ", page.Err.Error(), color.FgRed)
+			return
+		}
+		utils.PrintColored("Fetched: ", page.URL, color.FgHiGreen)
+		records, err := parser.ParseHTML(page.Content, page.URL, cfg)
+		if err != nil {
+			utils.PrintColored("Failed to parse: ", page.URL+": "+err.Error(), color.FgRed)
+			return
+		}
+		for _, record := range records {
+			record.FetchedAt = page.FetchedAt
+			// A failed transform leaves its field unconverted; the record is still kept.
+			if err := postProcessor.Process(record); err != nil {
+				for _, line := range strings.Split(err.Error(), "\n") {
+					utils.PrintColored("Transform failed, keeping value: ", page.URL+": "+line, color.FgYellow)
+				}
+			}
+		}
+		if collect {
+			results = append(results, records...)
+			parsed = append(parsed, page.URL)
+		}
+		for i, sink := range sinks {
+			for _, record := range records {
+				if broken[i] {
+					break
+				}
+				if err := sink.Write(record); err != nil {
+					utils.PrintColored("Failed to save data: ", err.Error(), color.FgRed)
+					broken[i] = true
+					failed = true
+				}
+			}
+		}
+	})
+	// A failed crawl must not replace earlier output; an interrupted one saves what it has.
+	if err != nil && !errors.Is(err, context.Canceled) {
+		for _, sink := range sinks {
+			sink.Discard()
+		}
+		utils.PrintColored("Crawl failed: ", err.Error(), color.FgRed)
+		os.Exit(1)
+	}
+	for i, sink := range sinks {
+		// A sink that failed a write was already reported.
+		err := sink.Close()
+		switch {
+		case broken[i]:
+		case err != nil:
+			utils.PrintColored("Failed to save data: ", err.Error(), color.FgRed)
+			failed = true
+		default:
+			utils.PrintColored("Saved results to: ", sink.Path(), color.FgGreen)
+		}
+	}
+	if errors.Is(err, context.Canceled) {
+		utils.PrintColored("Crawl interrupted; saving partial results.", "", color.FgYellow)
+	}
+
+	// Save the results in each remaining output format.
+	for _, option := range options {
+		if streamed[option] {
+			continue
+		}
+		path, err := storage.SaveCrawl(results, parsed, cfg, option)
+		if err != nil {
+			utils.PrintColored("Failed to save data: ", err.Error(), color.FgRed)
+			failed = true
+			continue
+		}
+		utils.PrintColored("Saved results to: ", path, color.FgGreen)
+	}
+	if failed {
+		os.Exit(1)
 	}
 }
@@ -5,11 +5,26 @@ go 1.23.4
 
 require (
 	bou.ke/monkey v1.0.2
+	github.com/PuerkitoBio/goquery v1.10.1
+	github.com/andybalholm/cascadia v1.3.3
+	github.com/antchfx/htmlquery v1.3.6
+	github.com/antchfx/xpath v1.3.8
 	github.com/fatih/color v1.18.0
+	github.com/mattn/go-sqlite3 v1.14.32
+	github.com/xuri/excelize/v2 v2.9.0
+	golang.org/x/net v0.34.0
+	golang.org/x/text v0.21.0
 )
 
 require (
+	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
-	golang.org/x/sys v0.25.0 // indirect
+	github.com/mohae/deepcopy v0.0.0-20170929034955-c48cc78d4826 // indirect
+	github.com/richardlehane/mscfb v1.0.4 // indirect
+	github.com/richardlehane/msoleps v1.0.4 // indirect
+	github.com/xuri/efp v0.0.0-20240408161823-9ad904a10d6d // indirect
+	github.com/xuri/nfp v0.0.0-20240318013403-ab9948c2c4a7 // indirect
+	golang.org/x/crypto v0.32.0 // indirect
+	golang.org/x/sys v0.29.0 // indirect
 )
@@ -4,7 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/andybalholm/cascadia"
 	"github.com/fatih/color"
 	"github.com/heinrichb/scrapey-cli/pkg/utils"
 )
@@ -21,7 +26,7 @@ var Verbose bool
 Config holds configuration data used by Scrapey CLI.
 
 Fields:
-  - URL: A struct containing the base URL and routes to scrape.
+  - URL: A struct containing the base URL, routes and sitemaps to scrape.
   - ParseRules: A struct containing parsing rules.
   - Storage: A struct defining how data is saved.
   - ScrapingOptions: Settings for crawling behavior.
@@ -37,28 +42,70 @@ type Config struct {
 		Base        string   `json:"base"`
 		Routes      []string `json:"routes"`
 		IncludeBase bool     `json:"includeBase"`
+		Sitemaps    struct {
+			Enabled      bool     `json:"enabled"`
+			URLs         []string `json:"urls"`
+			LastmodAfter string   `json:"lastmodAfter"`
+		} `json:"sitemaps"`
 	} `json:"url"`
+	// ParseRules holds the legacy shorthand selectors plus arbitrary named Fields (see
+	// FieldRules). Items, when set, selects the repeating elements of a listing page;
+	// each yields its own record from the nested Items.Fields.
 	ParseRules struct {
-		Title           string `json:"title,omitempty"`
-		MetaDescription string `json:"metaDescription,omitempty"`
-		ArticleContent  string `json:"articleContent,omitempty"`
-		Author          string `json:"author,omitempty"`
-		DatePublished   string `json:"datePublished,omitempty"`
+		Title           string               `json:"title,omitempty"`
+		MetaDescription string               `json:"metaDescription,omitempty"`
+		ArticleContent  string               `json:"articleContent,omitempty"`
+		Author          string               `json:"author,omitempty"`
+		DatePublished   string               `json:"datePublished,omitempty"`
+		Fields          map[string]FieldRule `json:"fields,omitempty"`
+		Items           *FieldRule           `json:"items,omitempty"`
 	} `json:"parseRules"`
 	Storage struct {
 		OutputFormats []string `json:"outputFormats"`
 		SavePath      string   `json:"savePath"`
 		FileName      string   `json:"fileName"`
+		// CSV sets the field Delimiter, the ListSeparator joining list values within a
+		// cell, and SkipHeader to omit the header row.
+		CSV struct {
+			Delimiter     string `json:"delimiter"`
+			ListSeparator string `json:"listSeparator"`
+			SkipHeader    bool   `json:"skipHeader"`
+		} `json:"csv"`
+		// XML names the RootElement and ItemElement and, with MetadataAttributes, writes
+		// each record's URL and fetch time as attributes instead of child elements.
+		XML struct {
+			RootElement        string `json:"rootElement"`
+			ItemElement        string `json:"itemElement"`
+			MetadataAttributes bool   `json:"metadataAttributes"`
+		} `json:"xml"`
 	} `json:"storage"`
 	ScrapingOptions struct {
-		MaxDepth      int     `json:"maxDepth"`
-		RateLimit     float64 `json:"rateLimit"`
-		RetryAttempts int     `json:"retryAttempts"`
-		UserAgent     string  `json:"userAgent"`
+		MaxDepth           int     `json:"maxDepth"`
+		RateLimit          float64 `json:"rateLimit"`
+		RetryAttempts      int     `json:"retryAttempts"`
+		UserAgent          string  `json:"userAgent"`
+		Burst              int     `json:"burst"`
+		Concurrency        int     `json:"concurrency"`
+		PerHostConcurrency int     `json:"perHostConcurrency"`
+		IgnoreRobots       bool    `json:"ignoreRobots"`
+		// Pagination follows "next" links (NextSelector) or numbered URLs (URLTemplate
+		// with a "{page}" placeholder) from each seed, up to MaxPages pages (0 for no
+		// limit), independently of MaxDepth.
+		Pagination struct {
+			NextSelector string `json:"nextSelector"`
+			URLTemplate  string `json:"urlTemplate"`
+			MaxPages     int    `json:"maxPages"`
+		} `json:"pagination"`
 	} `json:"scrapingOptions"`
 	DataFormatting struct {
 		CleanWhitespace bool `json:"cleanWhitespace"`
 		RemoveHTML      bool `json:"removeHTML"`
+		// DateLayouts are Go time layouts tried before the built-in ones.
+		DateLayouts []string `json:"dateLayouts"`
+		// Timezone is the IANA zone assumed for dates without an offset, UTC if empty.
+		Timezone string `json:"timezone"`
+		// ContentFormat ("html", "markdown" or "text") sets how articleContent is output.
+		ContentFormat string `json:"contentFormat"`
 	} `json:"dataFormatting"`
 }
 
@@ -73,31 +120,150 @@ type ConfigOverride struct {
 		Base        *string   `json:"base"`
 		Routes      *[]string `json:"routes"`
 		IncludeBase *bool     `json:"includeBase"`
+		Sitemaps    *struct {
+			Enabled      *bool     `json:"enabled"`
+			URLs         *[]string `json:"urls"`
+			LastmodAfter *string   `json:"lastmodAfter"`
+		} `json:"sitemaps"`
 	} `json:"url"`
 	ParseRules *struct {
-		Title           *string `json:"title,omitempty"`
-		MetaDescription *string `json:"metaDescription,omitempty"`
-		ArticleContent  *string `json:"articleContent,omitempty"`
-		Author          *string `json:"author,omitempty"`
-		DatePublished   *string `json:"datePublished,omitempty"`
+		Title           *string               `json:"title,omitempty"`
+		MetaDescription *string               `json:"metaDescription,omitempty"`
+		ArticleContent  *string               `json:"articleContent,omitempty"`
+		Author          *string               `json:"author,omitempty"`
+		DatePublished   *string               `json:"datePublished,omitempty"`
+		Fields          *map[string]FieldRule `json:"fields,omitempty"`
+		Items           *FieldRule            `json:"items,omitempty"`
 	} `json:"parseRules"`
 	Storage *struct {
 		OutputFormats *[]string `json:"outputFormats"`
 		SavePath      *string   `json:"savePath"`
 		FileName      *string   `json:"fileName"`
+		CSV           *struct {
+			Delimiter     *string `json:"delimiter"`
+			ListSeparator *string `json:"listSeparator"`
+			SkipHeader    *bool   `json:"skipHeader"`
+		} `json:"csv"`
+		XML *struct {
+			RootElement        *string `json:"rootElement"`
+			ItemElement        *string `json:"itemElement"`
+			MetadataAttributes *bool   `json:"metadataAttributes"`
+		} `json:"xml"`
 	} `json:"storage"`
 	ScrapingOptions *struct {
-		MaxDepth      *int     `json:"maxDepth"`
-		RateLimit     *float64 `json:"rateLimit"`
-		RetryAttempts *int     `json:"retryAttempts"`
-		UserAgent     *string  `json:"userAgent"`
+		MaxDepth           *int     `json:"maxDepth"`
+		RateLimit          *float64 `json:"rateLimit"`
+		RetryAttempts      *int     `json:"retryAttempts"`
+		UserAgent          *string  `json:"userAgent"`
+		Burst              *int     `json:"burst"`
+		Concurrency        *int     `json:"concurrency"`
+		PerHostConcurrency *int     `json:"perHostConcurrency"`
+		IgnoreRobots       *bool    `json:"ignoreRobots"`
+		Pagination         *struct {
+			NextSelector *string `json:"nextSelector"`
+			URLTemplate  *string `json:"urlTemplate"`
+			MaxPages     *int    `json:"maxPages"`
+		} `json:"pagination"`
 	} `json:"scrapingOptions"`
 	DataFormatting *struct {
-		CleanWhitespace *bool `json:"cleanWhitespace"`
-		RemoveHTML      *bool `json:"removeHTML"`
+		CleanWhitespace *bool     `json:"cleanWhitespace"`
+		RemoveHTML      *bool     `json:"removeHTML"`
+		DateLayouts     *[]string `json:"dateLayouts"`
+		Timezone        *string   `json:"timezone"`
+		ContentFormat   *string   `json:"contentFormat"`
 	} `json:"dataFormatting"`
 }
 
+/*
+ValidatePagination checks ScrapingOptions.Pagination.
+
+Returns:
+  - An error if NextSelector is not a valid CSS selector, URLTemplate lacks the "{page}"
+    placeholder, or MaxPages is negative; nil otherwise.
+*/
+func (cfg *Config) ValidatePagination() error {
+	p := cfg.ScrapingOptions.Pagination
+	if p.NextSelector != "" {
+		if _, err := cascadia.Compile(p.NextSelector); err != nil {
+			return fmt.Errorf("invalid next selector %q: %v", p.NextSelector, err)
+		}
+	}
+	if p.URLTemplate != "" && !strings.Contains(p.URLTemplate, "{page}") {
+		return fmt.Errorf("URL template %q has no {page} placeholder", p.URLTemplate)
+	}
+	if p.MaxPages < 0 {
+		return fmt.Errorf("maxPages must not be negative, got %d", p.MaxPages)
+	}
+	return nil
+}
+
+// lastmodLayouts are the W3C Datetime forms accepted for sitemap dates, most specific first.
+var lastmodLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+/*
+ParseLastmod parses a W3C Datetime value, as used in sitemap <lastmod> elements and
+URL.Sitemaps.LastmodAfter.
+
+Returns:
+  - The time, and false if value is not a W3C Datetime.
+*/
+func ParseLastmod(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range lastmodLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+/*
+ValidateSitemaps checks URL.Sitemaps.
+
+Returns:
+  - An error if LastmodAfter is set but is not a W3C Datetime; nil otherwise.
+*/
+func (cfg *Config) ValidateSitemaps() error {
+	if after := cfg.URL.Sitemaps.LastmodAfter; after != "" {
+		if _, ok := ParseLastmod(after); !ok {
+			return fmt.Errorf("invalid lastmodAfter %q: expected a W3C datetime such as 2024-01-31", after)
+		}
+	}
+	return nil
+}
+
+// xmlElementName matches the element names accepted for Storage.XML.
+var xmlElementName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+/*
+ValidateStorage checks the Storage settings.
+
+Returns:
+  - An error if Storage.CSV.Delimiter is not a single character, or is a quote or line
+    break, or if Storage.XML.RootElement or ItemElement is not a valid XML element name;
+    nil otherwise.
+*/
+func (cfg *Config) ValidateStorage() error {
+	if d := cfg.Storage.CSV.Delimiter; d != "" {
+		r, size := utf8.DecodeRuneInString(d)
+		if size != len(d) || r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+			return fmt.Errorf("invalid CSV delimiter %q: must be a single character other than a quote or line break", d)
+		}
+	}
+	for _, name := range []string{cfg.Storage.XML.RootElement, cfg.Storage.XML.ItemElement} {
+		if name != "" && (!xmlElementName.MatchString(name) || strings.HasPrefix(strings.ToLower(name), "xml")) {
+			return fmt.Errorf("invalid XML element name %q", name)
+		}
+	}
+	return nil
+}
+
 /*
 ApplyDefaults populates missing fields in the Config struct with default values.
 
@@ -129,6 +295,15 @@ func (cfg *Config) ApplyDefaults() {
 	if cfg.ScrapingOptions.UserAgent == "" {
 		cfg.ScrapingOptions.UserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36"
 	}
+	if cfg.ScrapingOptions.Burst == 0 {
+		cfg.ScrapingOptions.Burst = 1
+	}
+	if cfg.ScrapingOptions.Concurrency == 0 {
+		cfg.ScrapingOptions.Concurrency = 4
+	}
+	if cfg.ScrapingOptions.PerHostConcurrency == 0 {
+		cfg.ScrapingOptions.PerHostConcurrency = 2
+	}
 	if len(cfg.Storage.OutputFormats) == 0 {
 		cfg.Storage.OutputFormats = []string{"json"}
 	}
@@ -138,6 +313,18 @@ func (cfg *Config) ApplyDefaults() {
 	if cfg.Storage.FileName == "" {
 		cfg.Storage.FileName = "scraped_data"
 	}
+	if cfg.Storage.CSV.Delimiter == "" {
+		cfg.Storage.CSV.Delimiter = ","
+	}
+	if cfg.Storage.CSV.ListSeparator == "" {
+		cfg.Storage.CSV.ListSeparator = "; "
+	}
+	if cfg.Storage.XML.RootElement == "" {
+		cfg.Storage.XML.RootElement = "records"
+	}
+	if cfg.Storage.XML.ItemElement == "" {
+		cfg.Storage.XML.ItemElement = "record"
+	}
 }
 
 /*
@@ -148,7 +335,8 @@ Parameters:
 
 Returns:
   - A pointer to a Config struct containing the parsed configuration.
-  - An error if the file does not exist, cannot be read, or if the JSON is invalid.
+  - An error if the file does not exist, cannot be read, if the JSON is invalid,
+    or if a parse rule has an unknown type or a selector that does not compile.
 
 Usage:
 
@@ -175,6 +363,20 @@ func Load(filePath string) (*Config, error) {
 		return nil, fmt.Errorf("invalid JSON in config file: %v", err)
 	}
 
+	// Reject rules whose selectors cannot be compiled before any page is fetched.
+	if err := cfg.ValidateRules(); err != nil {
+		return nil, fmt.Errorf("invalid parse rules in config file: %v", err)
+	}
+	if err := cfg.ValidateSitemaps(); err != nil {
+		return nil, fmt.Errorf("invalid sitemaps in config file: %v", err)
+	}
+	if err := cfg.ValidatePagination(); err != nil {
+		return nil, fmt.Errorf("invalid pagination in config file: %v", err)
+	}
+	if err := cfg.ValidateStorage(); err != nil {
+		return nil, fmt.Errorf("invalid storage options in config file: %v", err)
+	}
+
 	// Apply default values where necessary.
 	cfg.ApplyDefaults()
 
@@ -201,14 +403,28 @@ Usage:
 			Base        *string   `json:"base"`
 			Routes      *[]string `json:"routes"`
 			IncludeBase *bool     `json:"includeBase"`
+			Sitemaps    *struct {
+				Enabled      *bool     `json:"enabled"`
+				URLs         *[]string `json:"urls"`
+				LastmodAfter *string   `json:"lastmodAfter"`
+			} `json:"sitemaps"`
 		}{
 			Base: ptrString("https://example.org"),
 		},
 		ScrapingOptions: &struct {
-			MaxDepth      *int     `json:"maxDepth"`
-			RateLimit     *float64 `json:"rateLimit"`
-			RetryAttempts *int     `json:"retryAttempts"`
-			UserAgent     *string  `json:"userAgent"`
+			MaxDepth           *int     `json:"maxDepth"`
+			RateLimit          *float64 `json:"rateLimit"`
+			RetryAttempts      *int     `json:"retryAttempts"`
+			UserAgent          *string  `json:"userAgent"`
+			Burst              *int     `json:"burst"`
+			Concurrency        *int     `json:"concurrency"`
+			PerHostConcurrency *int     `json:"perHostConcurrency"`
+			IgnoreRobots       *bool    `json:"ignoreRobots"`
+			Pagination         *struct {
+				NextSelector *string `json:"nextSelector"`
+				URLTemplate  *string `json:"urlTemplate"`
+				MaxPages     *int    `json:"maxPages"`
+			} `json:"pagination"`
 		}{
 			MaxDepth: ptrInt(5),
 		},
@@ -240,6 +456,20 @@ func (cfg *Config) OverrideConfig(overrides ConfigOverride) {
 			utils.PrintColored("Overriding URL.IncludeBase: ", fmt.Sprint(*overrides.URL.IncludeBase), color.FgHiMagenta)
 			cfg.URL.IncludeBase = *overrides.URL.IncludeBase
 		}
+		if overrides.URL.Sitemaps != nil {
+			if overrides.URL.Sitemaps.Enabled != nil {
+				utils.PrintColored("Overriding URL.Sitemaps.Enabled: ", fmt.Sprint(*overrides.URL.Sitemaps.Enabled), color.FgHiMagenta)
+				cfg.URL.Sitemaps.Enabled = *overrides.URL.Sitemaps.Enabled
+			}
+			if overrides.URL.Sitemaps.URLs != nil {
+				utils.PrintColored("Overriding URL.Sitemaps.URLs: ", fmt.Sprint(*overrides.URL.Sitemaps.URLs), color.FgHiMagenta)
+				cfg.URL.Sitemaps.URLs = *overrides.URL.Sitemaps.URLs
+			}
+			if overrides.URL.Sitemaps.LastmodAfter != nil {
+				utils.PrintColored("Overriding URL.Sitemaps.LastmodAfter: ", *overrides.URL.Sitemaps.LastmodAfter, color.FgHiMagenta)
+				cfg.URL.Sitemaps.LastmodAfter = *overrides.URL.Sitemaps.LastmodAfter
+			}
+		}
 	}
 
 	// Override ParseRules fields.
@@ -264,6 +494,14 @@ func (cfg *Config) OverrideConfig(overrides ConfigOverride) {
 			utils.PrintColored("Overriding ParseRules.DatePublished: ", *overrides.ParseRules.DatePublished, color.FgHiMagenta)
 			cfg.ParseRules.DatePublished = *overrides.ParseRules.DatePublished
 		}
+		if overrides.ParseRules.Fields != nil {
+			utils.PrintColored("Overriding ParseRules.Fields: ", fmt.Sprint(*overrides.ParseRules.Fields), color.FgHiMagenta)
+			cfg.ParseRules.Fields = *overrides.ParseRules.Fields
+		}
+		if overrides.ParseRules.Items != nil {
+			utils.PrintColored("Overriding ParseRules.Items: ", overrides.ParseRules.Items.Selector, color.FgHiMagenta)
+			cfg.ParseRules.Items = overrides.ParseRules.Items
+		}
 	}
 
 	// Override Storage fields.
@@ -280,6 +518,34 @@ func (cfg *Config) OverrideConfig(overrides ConfigOverride) {
 			utils.PrintColored("Overriding Storage.FileName: ", *overrides.Storage.FileName, color.FgHiMagenta)
 			cfg.Storage.FileName = *overrides.Storage.FileName
 		}
+		if c := overrides.Storage.CSV; c != nil {
+			if c.Delimiter != nil {
+				utils.PrintColored("Overriding Storage.CSV.Delimiter: ", *c.Delimiter, color.FgHiMagenta)
+				cfg.Storage.CSV.Delimiter = *c.Delimiter
+			}
+			if c.ListSeparator != nil {
+				utils.PrintColored("Overriding Storage.CSV.ListSeparator: ", *c.ListSeparator, color.FgHiMagenta)
+				cfg.Storage.CSV.ListSeparator = *c.ListSeparator
+			}
+			if c.SkipHeader != nil {
+				utils.PrintColored("Overriding Storage.CSV.SkipHeader: ", fmt.Sprint(*c.SkipHeader), color.FgHiMagenta)
+				cfg.Storage.CSV.SkipHeader = *c.SkipHeader
+			}
+		}
+		if x := overrides.Storage.XML; x != nil {
+			if x.RootElement != nil {
+				utils.PrintColored("Overriding Storage.XML.RootElement: ", *x.RootElement, color.FgHiMagenta)
+				cfg.Storage.XML.RootElement = *x.RootElement
+			}
+			if x.ItemElement != nil {
+				utils.PrintColored("Overriding Storage.XML.ItemElement: ", *x.ItemElement, color.FgHiMagenta)
+				cfg.Storage.XML.ItemElement = *x.ItemElement
+			}
+			if x.MetadataAttributes != nil {
+				utils.PrintColored("Overriding Storage.XML.MetadataAttributes: ", fmt.Sprint(*x.MetadataAttributes), color.FgHiMagenta)
+				cfg.Storage.XML.MetadataAttributes = *x.MetadataAttributes
+			}
+		}
 	}
 
 	// Override ScrapingOptions fields.
@@ -300,6 +566,36 @@ func (cfg *Config) OverrideConfig(overrides ConfigOverride) {
 			utils.PrintColored("Overriding ScrapingOptions.UserAgent: ", *overrides.ScrapingOptions.UserAgent, color.FgHiMagenta)
 			cfg.ScrapingOptions.UserAgent = *overrides.ScrapingOptions.UserAgent
 		}
+		if overrides.ScrapingOptions.Burst != nil {
+			utils.PrintColored("Overriding ScrapingOptions.Burst: ", fmt.Sprint(*overrides.ScrapingOptions.Burst), color.FgHiMagenta)
+			cfg.ScrapingOptions.Burst = *overrides.ScrapingOptions.Burst
+		}
+		if overrides.ScrapingOptions.Concurrency != nil {
+			utils.PrintColored("Overriding ScrapingOptions.Concurrency: ", fmt.Sprint(*overrides.ScrapingOptions.Concurrency), color.FgHiMagenta)
+			cfg.ScrapingOptions.Concurrency = *overrides.ScrapingOptions.Concurrency
+		}
+		if overrides.ScrapingOptions.PerHostConcurrency != nil {
+			utils.PrintColored("Overriding ScrapingOptions.PerHostConcurrency: ", fmt.Sprint(*overrides.ScrapingOptions.PerHostConcurrency), color.FgHiMagenta)
+			cfg.ScrapingOptions.PerHostConcurrency = *overrides.ScrapingOptions.PerHostConcurrency
+		}
+		if overrides.ScrapingOptions.IgnoreRobots != nil {
+			utils.PrintColored("Overriding ScrapingOptions.IgnoreRobots: ", fmt.Sprint(*overrides.ScrapingOptions.IgnoreRobots), color.FgHiMagenta)
+			cfg.ScrapingOptions.IgnoreRobots = *overrides.ScrapingOptions.IgnoreRobots
+		}
+		if p := overrides.ScrapingOptions.Pagination; p != nil {
+			if p.NextSelector != nil {
+				utils.PrintColored("Overriding ScrapingOptions.Pagination.NextSelector: ", *p.NextSelector, color.FgHiMagenta)
+				cfg.ScrapingOptions.Pagination.NextSelector = *p.NextSelector
+			}
+			if p.URLTemplate != nil {
+				utils.PrintColored("Overriding ScrapingOptions.Pagination.URLTemplate: ", *p.URLTemplate, color.FgHiMagenta)
+				cfg.ScrapingOptions.Pagination.URLTemplate = *p.URLTemplate
+			}
+			if p.MaxPages != nil {
+				utils.PrintColored("Overriding ScrapingOptions.Pagination.MaxPages: ", fmt.Sprint(*p.MaxPages), color.FgHiMagenta)
+				cfg.ScrapingOptions.Pagination.MaxPages = *p.MaxPages
+			}
+		}
 	}
 
 	// Override DataFormatting fields.
@@ -312,5 +608,17 @@ func (cfg *Config) OverrideConfig(overrides ConfigOverride) {
 			utils.PrintColored("Overriding DataFormatting.RemoveHTML: ", fmt.Sprint(*overrides.DataFormatting.RemoveHTML), color.FgHiMagenta)
 			cfg.DataFormatting.RemoveHTML = *overrides.DataFormatting.RemoveHTML
 		}
+		if overrides.DataFormatting.DateLayouts != nil {
+			utils.PrintColored("Overriding DataFormatting.DateLayouts: ", fmt.Sprint(*overrides.DataFormatting.DateLayouts), color.FgHiMagenta)
+			cfg.DataFormatting.DateLayouts = *overrides.DataFormatting.DateLayouts
+		}
+		if overrides.DataFormatting.Timezone != nil {
+			utils.PrintColored("Overriding DataFormatting.Timezone: ", *overrides.DataFormatting.Timezone, color.FgHiMagenta)
+			cfg.DataFormatting.Timezone = *overrides.DataFormatting.Timezone
+		}
+		if overrides.DataFormatting.ContentFormat != nil {
+			utils.PrintColored("Overriding DataFormatting.ContentFormat: ", *overrides.DataFormatting.ContentFormat, color.FgHiMagenta)
+			cfg.DataFormatting.ContentFormat = *overrides.DataFormatting.ContentFormat
+		}
 	}
 }
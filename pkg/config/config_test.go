@@ -49,6 +49,15 @@ func TestApplyDefaults(t *testing.T) {
 				if cfg.ScrapingOptions.UserAgent != expectedUA {
 					t.Errorf("Expected ScrapingOptions.UserAgent to be '%s', got '%s'", expectedUA, cfg.ScrapingOptions.UserAgent)
 				}
+				if cfg.ScrapingOptions.Burst != 1 {
+					t.Errorf("Expected ScrapingOptions.Burst to be 1, got %d", cfg.ScrapingOptions.Burst)
+				}
+				if cfg.ScrapingOptions.Concurrency != 4 {
+					t.Errorf("Expected ScrapingOptions.Concurrency to be 4, got %d", cfg.ScrapingOptions.Concurrency)
+				}
+				if cfg.ScrapingOptions.PerHostConcurrency != 2 {
+					t.Errorf("Expected ScrapingOptions.PerHostConcurrency to be 2, got %d", cfg.ScrapingOptions.PerHostConcurrency)
+				}
 				if len(cfg.Storage.OutputFormats) != 1 || cfg.Storage.OutputFormats[0] != "json" {
 					t.Errorf("Expected Storage.OutputFormats to be ['json'], got %v", cfg.Storage.OutputFormats)
 				}
@@ -58,6 +67,12 @@ func TestApplyDefaults(t *testing.T) {
 				if cfg.Storage.FileName != "scraped_data" {
 					t.Errorf("Expected Storage.FileName to be 'scraped_data', got '%s'", cfg.Storage.FileName)
 				}
+				if cfg.Storage.CSV.Delimiter != "," || cfg.Storage.CSV.ListSeparator != "; " {
+					t.Errorf("Expected Storage.CSV defaults ',' and '; ', got %+v", cfg.Storage.CSV)
+				}
+				if cfg.Storage.XML.RootElement != "records" || cfg.Storage.XML.ItemElement != "record" {
+					t.Errorf("Expected Storage.XML defaults 'records' and 'record', got %+v", cfg.Storage.XML)
+				}
 			},
 		},
 		{
@@ -206,6 +221,81 @@ func TestLoad(t *testing.T) {
 				}
 			},
 		},
+		{
+			desc: "Invalid XPath parse rule",
+			fileSetup: func(name string) {
+				if err := os.WriteFile(name, []byte(`{"parseRules": {"fields": {"x": {"selector": "//div[", "type": "xpath"}}}}`), 0644); err != nil {
+					t.Fatalf("Failed to write file: %v", err)
+				}
+			},
+			verbose:   false,
+			expectErr: true,
+			checkOutput: func(t *testing.T, colored, nonEmpty string) {
+				if !strings.Contains(colored, "Loaded config from: ") {
+					t.Errorf("Expected colored output, got: %s", colored)
+				}
+			},
+		},
+		{
+			desc: "Invalid sitemap lastmodAfter",
+			fileSetup: func(name string) {
+				if err := os.WriteFile(name, []byte(`{"url": {"sitemaps": {"enabled": true, "lastmodAfter": "last week"}}}`), 0644); err != nil {
+					t.Fatalf("Failed to write file: %v", err)
+				}
+			},
+			verbose:   false,
+			expectErr: true,
+			checkOutput: func(t *testing.T, colored, nonEmpty string) {
+				if !strings.Contains(colored, "Loaded config from: ") {
+					t.Errorf("Expected colored output, got: %s", colored)
+				}
+			},
+		},
+		{
+			desc: "Invalid pagination template",
+			fileSetup: func(name string) {
+				if err := os.WriteFile(name, []byte(`{"scrapingOptions": {"pagination": {"urlTemplate": "/page/2"}}}`), 0644); err != nil {
+					t.Fatalf("Failed to write file: %v", err)
+				}
+			},
+			verbose:   false,
+			expectErr: true,
+			checkOutput: func(t *testing.T, colored, nonEmpty string) {
+				if !strings.Contains(colored, "Loaded config from: ") {
+					t.Errorf("Expected colored output, got: %s", colored)
+				}
+			},
+		},
+		{
+			desc: "Invalid CSV delimiter",
+			fileSetup: func(name string) {
+				if err := os.WriteFile(name, []byte(`{"storage": {"csv": {"delimiter": ";;"}}}`), 0644); err != nil {
+					t.Fatalf("Failed to write file: %v", err)
+				}
+			},
+			verbose:   false,
+			expectErr: true,
+			checkOutput: func(t *testing.T, colored, nonEmpty string) {
+				if !strings.Contains(colored, "Loaded config from: ") {
+					t.Errorf("Expected colored output, got: %s", colored)
+				}
+			},
+		},
+		{
+			desc: "Invalid XML element name",
+			fileSetup: func(name string) {
+				if err := os.WriteFile(name, []byte(`{"storage": {"xml": {"itemElement": "my item"}}}`), 0644); err != nil {
+					t.Fatalf("Failed to write file: %v", err)
+				}
+			},
+			verbose:   false,
+			expectErr: true,
+			checkOutput: func(t *testing.T, colored, nonEmpty string) {
+				if !strings.Contains(colored, "Loaded config from: ") {
+					t.Errorf("Expected colored output, got: %s", colored)
+				}
+			},
+		},
 		{
 			desc: "Valid JSON without verbose mode",
 			fileSetup: func(name string) {
@@ -313,50 +403,124 @@ func TestOverrideConfig(t *testing.T) {
 						Base        *string   `json:"base"`
 						Routes      *[]string `json:"routes"`
 						IncludeBase *bool     `json:"includeBase"`
+						Sitemaps    *struct {
+							Enabled      *bool     `json:"enabled"`
+							URLs         *[]string `json:"urls"`
+							LastmodAfter *string   `json:"lastmodAfter"`
+						} `json:"sitemaps"`
 					}{
 						Base:        ptrString("https://override.com"),
 						Routes:      &[]string{"/new", "/extra"},
 						IncludeBase: ptrBool(true),
+						Sitemaps: &struct {
+							Enabled      *bool     `json:"enabled"`
+							URLs         *[]string `json:"urls"`
+							LastmodAfter *string   `json:"lastmodAfter"`
+						}{
+							Enabled:      ptrBool(true),
+							URLs:         &[]string{"/sitemap_index.xml"},
+							LastmodAfter: ptrString("2024-01-01"),
+						},
 					},
 					ParseRules: &struct {
-						Title           *string `json:"title,omitempty"`
-						MetaDescription *string `json:"metaDescription,omitempty"`
-						ArticleContent  *string `json:"articleContent,omitempty"`
-						Author          *string `json:"author,omitempty"`
-						DatePublished   *string `json:"datePublished,omitempty"`
+						Title           *string               `json:"title,omitempty"`
+						MetaDescription *string               `json:"metaDescription,omitempty"`
+						ArticleContent  *string               `json:"articleContent,omitempty"`
+						Author          *string               `json:"author,omitempty"`
+						DatePublished   *string               `json:"datePublished,omitempty"`
+						Fields          *map[string]FieldRule `json:"fields,omitempty"`
+						Items           *FieldRule            `json:"items,omitempty"`
 					}{
 						Title:           ptrString("New Title"),
 						MetaDescription: ptrString("New Meta"),
 						ArticleContent:  ptrString("New Content"),
 						Author:          ptrString("New Author"),
 						DatePublished:   ptrString("2022-01-01"),
+						Fields:          &map[string]FieldRule{"price": {Selector: ".price", Required: true}},
+						Items:           &FieldRule{Selector: ".product", Fields: map[string]FieldRule{"name": {Selector: "h2"}}},
 					},
 					Storage: &struct {
 						OutputFormats *[]string `json:"outputFormats"`
 						SavePath      *string   `json:"savePath"`
 						FileName      *string   `json:"fileName"`
+						CSV           *struct {
+							Delimiter     *string `json:"delimiter"`
+							ListSeparator *string `json:"listSeparator"`
+							SkipHeader    *bool   `json:"skipHeader"`
+						} `json:"csv"`
+						XML *struct {
+							RootElement        *string `json:"rootElement"`
+							ItemElement        *string `json:"itemElement"`
+							MetadataAttributes *bool   `json:"metadataAttributes"`
+						} `json:"xml"`
 					}{
 						OutputFormats: &[]string{"csv"},
 						SavePath:      ptrString("new_output/"),
 						FileName:      ptrString("new_data"),
+						CSV: &struct {
+							Delimiter     *string `json:"delimiter"`
+							ListSeparator *string `json:"listSeparator"`
+							SkipHeader    *bool   `json:"skipHeader"`
+						}{
+							Delimiter:     ptrString(";"),
+							ListSeparator: ptrString(" | "),
+							SkipHeader:    ptrBool(true),
+						},
+						XML: &struct {
+							RootElement        *string `json:"rootElement"`
+							ItemElement        *string `json:"itemElement"`
+							MetadataAttributes *bool   `json:"metadataAttributes"`
+						}{
+							RootElement:        ptrString("products"),
+							ItemElement:        ptrString("product"),
+							MetadataAttributes: ptrBool(true),
+						},
 					},
 					ScrapingOptions: &struct {
-						MaxDepth      *int     `json:"maxDepth"`
-						RateLimit     *float64 `json:"rateLimit"`
-						RetryAttempts *int     `json:"retryAttempts"`
-						UserAgent     *string  `json:"userAgent"`
+						MaxDepth           *int     `json:"maxDepth"`
+						RateLimit          *float64 `json:"rateLimit"`
+						RetryAttempts      *int     `json:"retryAttempts"`
+						UserAgent          *string  `json:"userAgent"`
+						Burst              *int     `json:"burst"`
+						Concurrency        *int     `json:"concurrency"`
+						PerHostConcurrency *int     `json:"perHostConcurrency"`
+						IgnoreRobots       *bool    `json:"ignoreRobots"`
+						Pagination         *struct {
+							NextSelector *string `json:"nextSelector"`
+							URLTemplate  *string `json:"urlTemplate"`
+							MaxPages     *int    `json:"maxPages"`
+						} `json:"pagination"`
 					}{
-						MaxDepth:      ptrInt(5),
-						RateLimit:     ptrFloat64(2.0),
-						RetryAttempts: ptrInt(4),
-						UserAgent:     ptrString("OverrideAgent"),
+						MaxDepth:           ptrInt(5),
+						RateLimit:          ptrFloat64(2.0),
+						RetryAttempts:      ptrInt(4),
+						UserAgent:          ptrString("OverrideAgent"),
+						Burst:              ptrInt(3),
+						Concurrency:        ptrInt(8),
+						PerHostConcurrency: ptrInt(3),
+						IgnoreRobots:       ptrBool(true),
+						Pagination: &struct {
+							NextSelector *string `json:"nextSelector"`
+							URLTemplate  *string `json:"urlTemplate"`
+							MaxPages     *int    `json:"maxPages"`
+						}{
+							NextSelector: ptrString("a[rel=next]"),
+							URLTemplate:  ptrString("?page={page}"),
+							MaxPages:     ptrInt(10),
+						},
 					},
 					DataFormatting: &struct {
-						CleanWhitespace *bool `json:"cleanWhitespace"`
-						RemoveHTML      *bool `json:"removeHTML"`
+						CleanWhitespace *bool     `json:"cleanWhitespace"`
+						RemoveHTML      *bool     `json:"removeHTML"`
+						DateLayouts     *[]string `json:"dateLayouts"`
+						Timezone        *string   `json:"timezone"`
+						ContentFormat   *string   `json:"contentFormat"`
 					}{
 						CleanWhitespace: ptrBool(true),
 						RemoveHTML:      ptrBool(true),
+						DateLayouts:     &[]string{"02/01/2006"},
+						Timezone:        ptrString("Europe/Berlin"),
+						ContentFormat:   ptrString("markdown"),
 					},
 				}
 			},
@@ -373,6 +537,15 @@ func TestOverrideConfig(t *testing.T) {
 				if !base.URL.IncludeBase {
 					t.Errorf("Expected URL.IncludeBase to be true")
 				}
+				if !base.URL.Sitemaps.Enabled {
+					t.Errorf("Expected URL.Sitemaps.Enabled to be true")
+				}
+				if !reflect.DeepEqual(base.URL.Sitemaps.URLs, []string{"/sitemap_index.xml"}) {
+					t.Errorf("Expected URL.Sitemaps.URLs to be ['/sitemap_index.xml'], got %v", base.URL.Sitemaps.URLs)
+				}
+				if base.URL.Sitemaps.LastmodAfter != "2024-01-01" {
+					t.Errorf("Expected URL.Sitemaps.LastmodAfter to be '2024-01-01', got '%s'", base.URL.Sitemaps.LastmodAfter)
+				}
 				if base.ParseRules.Title != "New Title" {
 					t.Errorf("Expected ParseRules.Title to be 'New Title', got '%s'", base.ParseRules.Title)
 				}
@@ -388,6 +561,12 @@ func TestOverrideConfig(t *testing.T) {
 				if base.ParseRules.DatePublished != "2022-01-01" {
 					t.Errorf("Expected ParseRules.DatePublished to be '2022-01-01', got '%s'", base.ParseRules.DatePublished)
 				}
+				if rule := base.ParseRules.Fields["price"]; rule.Selector != ".price" || !rule.Required {
+					t.Errorf("Expected ParseRules.Fields[price] to be a required '.price' rule, got %+v", rule)
+				}
+				if base.ParseRules.Items == nil || base.ParseRules.Items.Selector != ".product" {
+					t.Errorf("Expected ParseRules.Items to select '.product', got %+v", base.ParseRules.Items)
+				}
 				if !reflect.DeepEqual(base.Storage.OutputFormats, []string{"csv"}) {
 					t.Errorf("Expected Storage.OutputFormats to be ['csv'], got %v", base.Storage.OutputFormats)
 				}
@@ -397,6 +576,12 @@ func TestOverrideConfig(t *testing.T) {
 				if base.Storage.FileName != "new_data" {
 					t.Errorf("Expected Storage.FileName to be 'new_data', got '%s'", base.Storage.FileName)
 				}
+				if c := base.Storage.CSV; c.Delimiter != ";" || c.ListSeparator != " | " || !c.SkipHeader {
+					t.Errorf("Expected Storage.CSV to be overridden, got %+v", c)
+				}
+				if x := base.Storage.XML; x.RootElement != "products" || x.ItemElement != "product" || !x.MetadataAttributes {
+					t.Errorf("Expected Storage.XML to be overridden, got %+v", x)
+				}
 				if base.ScrapingOptions.MaxDepth != 5 {
 					t.Errorf("Expected ScrapingOptions.MaxDepth to be 5, got %d", base.ScrapingOptions.MaxDepth)
 				}
@@ -409,12 +594,36 @@ func TestOverrideConfig(t *testing.T) {
 				if base.ScrapingOptions.UserAgent != "OverrideAgent" {
 					t.Errorf("Expected ScrapingOptions.UserAgent to be 'OverrideAgent', got '%s'", base.ScrapingOptions.UserAgent)
 				}
+				if base.ScrapingOptions.Burst != 3 {
+					t.Errorf("Expected ScrapingOptions.Burst to be 3, got %d", base.ScrapingOptions.Burst)
+				}
+				if base.ScrapingOptions.Concurrency != 8 {
+					t.Errorf("Expected ScrapingOptions.Concurrency to be 8, got %d", base.ScrapingOptions.Concurrency)
+				}
+				if base.ScrapingOptions.PerHostConcurrency != 3 {
+					t.Errorf("Expected ScrapingOptions.PerHostConcurrency to be 3, got %d", base.ScrapingOptions.PerHostConcurrency)
+				}
+				if !base.ScrapingOptions.IgnoreRobots {
+					t.Errorf("Expected ScrapingOptions.IgnoreRobots to be true")
+				}
+				if p := base.ScrapingOptions.Pagination; p.NextSelector != "a[rel=next]" || p.URLTemplate != "?page={page}" || p.MaxPages != 10 {
+					t.Errorf("Expected ScrapingOptions.Pagination to be overridden, got %+v", p)
+				}
 				if !base.DataFormatting.CleanWhitespace {
 					t.Errorf("Expected DataFormatting.CleanWhitespace to be true")
 				}
 				if !base.DataFormatting.RemoveHTML {
 					t.Errorf("Expected DataFormatting.RemoveHTML to be true")
 				}
+				if len(base.DataFormatting.DateLayouts) != 1 || base.DataFormatting.DateLayouts[0] != "02/01/2006" {
+					t.Errorf("Expected DataFormatting.DateLayouts to be [02/01/2006], got %v", base.DataFormatting.DateLayouts)
+				}
+				if base.DataFormatting.Timezone != "Europe/Berlin" {
+					t.Errorf("Expected DataFormatting.Timezone to be 'Europe/Berlin', got '%s'", base.DataFormatting.Timezone)
+				}
+				if base.DataFormatting.ContentFormat != "markdown" {
+					t.Errorf("Expected DataFormatting.ContentFormat to be 'markdown', got '%s'", base.DataFormatting.ContentFormat)
+				}
 
 				// Verify that PrintColored was called for each overridden field.
 				expectedSubstrs := []string{
@@ -422,20 +631,41 @@ func TestOverrideConfig(t *testing.T) {
 					"Overriding URL.Base: https://override.com",
 					"Overriding URL.Routes: [",
 					"Overriding URL.IncludeBase: true",
+					"Overriding URL.Sitemaps.Enabled: true",
+					"Overriding URL.Sitemaps.URLs: [",
+					"Overriding URL.Sitemaps.LastmodAfter: 2024-01-01",
 					"Overriding ParseRules.Title: New Title",
 					"Overriding ParseRules.MetaDescription: New Meta",
 					"Overriding ParseRules.ArticleContent: New Content",
 					"Overriding ParseRules.Author: New Author",
 					"Overriding ParseRules.DatePublished: 2022-01-01",
+					"Overriding ParseRules.Fields: map[price:",
+					"Overriding ParseRules.Items: .product",
 					"Overriding Storage.OutputFormats: [",
 					"Overriding Storage.SavePath: new_output/",
 					"Overriding Storage.FileName: new_data",
+					"Overriding Storage.CSV.Delimiter: ;",
+					"Overriding Storage.CSV.ListSeparator:  | ",
+					"Overriding Storage.CSV.SkipHeader: true",
+					"Overriding Storage.XML.RootElement: products",
+					"Overriding Storage.XML.ItemElement: product",
+					"Overriding Storage.XML.MetadataAttributes: true",
 					"Overriding ScrapingOptions.MaxDepth: 5",
 					"Overriding ScrapingOptions.RateLimit: 2",
 					"Overriding ScrapingOptions.RetryAttempts: 4",
 					"Overriding ScrapingOptions.UserAgent: OverrideAgent",
+					"Overriding ScrapingOptions.Burst: 3",
+					"Overriding ScrapingOptions.Concurrency: 8",
+					"Overriding ScrapingOptions.PerHostConcurrency: 3",
+					"Overriding ScrapingOptions.IgnoreRobots: true",
+					"Overriding ScrapingOptions.Pagination.NextSelector: a[rel=next]",
+					"Overriding ScrapingOptions.Pagination.URLTemplate: ?page={page}",
+					"Overriding ScrapingOptions.Pagination.MaxPages: 10",
 					"Overriding DataFormatting.CleanWhitespace: true",
 					"Overriding DataFormatting.RemoveHTML: true",
+					"Overriding DataFormatting.DateLayouts: [02/01/2006]",
+					"Overriding DataFormatting.Timezone: Europe/Berlin",
+					"Overriding DataFormatting.ContentFormat: markdown",
 				}
 				for _, substr := range expectedSubstrs {
 					if !strings.Contains(captured, substr) {
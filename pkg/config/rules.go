@@ -0,0 +1,480 @@
+// File: pkg/config/rules.go
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/xpath"
+)
+
+// Rule types accepted in FieldRule.Type.
+const (
+	SelectorCSS   = "css"
+	SelectorXPath = "xpath"
+	RuleTable     = "table"
+)
+
+// ReadabilityReference is the Fallback reference to the page's main content, found by
+// scoring text and link density when no selector or structured data yields a value.
+const ReadabilityReference = "readability"
+
+// Content formats accepted in DataFormatting.ContentFormat.
+const (
+	ContentHTML     = "html"
+	ContentMarkdown = "markdown"
+	ContentText     = "text"
+)
+
+// referenceSources lists the structured-data sources a Fallback reference may name.
+// "jsonld", "microdata" and "rdfa" take a "Type.path" key; the rest name a meta property.
+var referenceSources = map[string]bool{
+	"jsonld":    true,
+	"microdata": true,
+	"rdfa":      true,
+	"og":        true,
+	"article":   true,
+	"product":   true,
+	"twitter":   true,
+}
+
+// shorthandFallbacks are the structured-data references tried for the legacy shorthand
+// keys when their selector matches nothing.
+var shorthandFallbacks = map[string][]string{
+	"title":           {"og:title", "twitter:title", "jsonld:*.headline", "microdata:*.headline"},
+	"metaDescription": {"og:description", "twitter:description", "jsonld:*.description"},
+	"articleContent":  {"jsonld:*.articleBody", "microdata:*.articleBody", ReadabilityReference},
+	"author":          {"jsonld:*.author.name", "microdata:*.author.name", "rdfa:*.author.name", "article:author"},
+	"datePublished":   {"article:published_time", "jsonld:*.datePublished", "microdata:*.datePublished", "rdfa:*.datePublished"},
+}
+
+// shorthandTransforms are the transforms applied to the legacy shorthand keys.
+var shorthandTransforms = map[string][]TransformSpec{
+	"datePublished": {{Name: "toDate"}},
+}
+
+// contentFormatTransforms convert "articleContent" to each DataFormatting.ContentFormat.
+var contentFormatTransforms = map[string][]TransformSpec{
+	"":              nil,
+	ContentHTML:     nil,
+	ContentMarkdown: {{Name: "toMarkdown"}},
+	ContentText:     {{Name: "stripHTML"}},
+}
+
+// attrSuffix matches the "@name" attribute shorthand at the end of a CSS selector.
+var attrSuffix = regexp.MustCompile(`^(.*?)\s*@([A-Za-z_:][-A-Za-z0-9_:.]*)$`)
+
+/*
+FieldRule describes how to extract a single named field from a page.
+
+Fields:
+  - Selector: The expression locating the field's element(s). For CSS selectors a
+    trailing "@name" (e.g. "a.next@href") is shorthand for Attribute.
+  - Type: The selector language, SelectorCSS (the default when empty) or SelectorXPath.
+    XPath 1.0 expressions may also select attribute or text nodes, or evaluate to a
+    string, number or boolean, which are used as the value directly. RuleTable selects
+    <table> elements with a CSS selector and yields one record per data row, keyed by
+    column header (see parser.ParseHTML); it cannot be combined with Attribute,
+    Default, Fallback, Transforms or Fields.
+  - Attribute: The attribute to read from matched elements. When empty, <meta> elements
+    yield their content attribute and all other elements their trimmed text.
+  - Multiple: Collect every match as a list instead of only the first.
+  - Required: Treat a page where the field is missing as a parse error. Inside Items, or
+    a Multiple rule with Fields, only the item missing it is skipped.
+  - Default: The value used when nothing matches.
+  - Fallback: Structured-data references tried in order when Selector matches nothing
+    (see ParseReference), e.g. "jsonld:Article.author.name" or "og:title". A rule with
+    a Fallback may omit Selector.
+  - Transforms: Named post-processing steps applied to the field's values, in order,
+    after the DataFormatting defaults (see parser.PostProcessor).
+  - Fields: Nested rules evaluated within each matched element, producing an object
+    instead of a string. Inside nested rules an empty Selector refers to the matched
+    element itself.
+
+Usage:
+
+	A rule may be written in JSON either as a full object or as a bare selector string:
+
+	"fields": {
+	    "price": { "selector": ".price", "required": true },
+	    "images": { "selector": "img.gallery", "attribute": "src", "multiple": true },
+	    "sku": { "selector": "[itemprop='sku']", "transforms": ["decodeEntities", "upper"] },
+	    "byline": { "selector": "//p[starts-with(., 'By ')]", "type": "xpath" },
+	    "specs": { "selector": "table.specs", "type": "table" },
+	    "brand": { "selector": ".brand", "fallback": ["jsonld:Product.brand.name", "og:brand"] },
+	    "reviews": {
+	        "selector": ".review",
+	        "multiple": true,
+	        "fields": { "rating": "@data-rating", "body": "p" }
+	    }
+	}
+*/
+type FieldRule struct {
+	Selector   string               `json:"selector"`
+	Type       string               `json:"type,omitempty"`
+	Attribute  string               `json:"attribute,omitempty"`
+	Multiple   bool                 `json:"multiple,omitempty"`
+	Required   bool                 `json:"required,omitempty"`
+	Default    string               `json:"default,omitempty"`
+	Fallback   []string             `json:"fallback,omitempty"`
+	Transforms []TransformSpec      `json:"transforms,omitempty"`
+	Fields     map[string]FieldRule `json:"fields,omitempty"`
+}
+
+/*
+TransformSpec names a post-processing step in a FieldRule's Transforms list, with the
+parameters that step takes.
+
+Fields:
+  - Name: The transform name (e.g. "stripHTML", "regex", "toNumber").
+  - Pattern: The regular expression for "regex" and "replace".
+  - Group: The capture group "regex" keeps; 0 selects the first group if the pattern
+    has one, otherwise the whole match.
+  - Replacement: The replacement for "replace"; "$1" expands capture groups.
+  - Chars: The characters "trim" removes; whitespace when empty.
+  - Separator: The separator for "split" (default ",") and "join" (default ", ").
+  - Decimal: The decimal separator for "toNumber", "." (default) or ",".
+  - Layouts: Go time layouts, or names such as "RFC3339", tried in order by "toDate".
+  - Value: The value "default" substitutes for an empty result.
+
+Usage:
+
+	A spec may be written in JSON either as an object or as a bare name string:
+
+	"transforms": [
+	    "stripHTML",
+	    { "name": "regex", "pattern": "([0-9.,]+)" },
+	    { "name": "toNumber" }
+	]
+*/
+type TransformSpec struct {
+	Name        string   `json:"name"`
+	Pattern     string   `json:"pattern,omitempty"`
+	Group       int      `json:"group,omitempty"`
+	Replacement string   `json:"replacement,omitempty"`
+	Chars       string   `json:"chars,omitempty"`
+	Separator   string   `json:"separator,omitempty"`
+	Decimal     string   `json:"decimal,omitempty"`
+	Layouts     []string `json:"layouts,omitempty"`
+	Value       string   `json:"value,omitempty"`
+}
+
+/*
+UnmarshalJSON decodes a TransformSpec from either a name string or a spec object.
+
+Returns:
+  - An error if the value is neither a string nor a valid spec object.
+*/
+func (t *TransformSpec) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '"' {
+		var name string
+		if err := json.Unmarshal(trimmed, &name); err != nil {
+			return fmt.Errorf("invalid transform: %v", err)
+		}
+		*t = TransformSpec{Name: name}
+		return nil
+	}
+
+	// Decode through an alias type so this method is not invoked recursively.
+	type transformSpec TransformSpec
+	var spec transformSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return fmt.Errorf("invalid transform: %v", err)
+	}
+	*t = TransformSpec(spec)
+	return nil
+}
+
+/*
+UnmarshalJSON decodes a FieldRule from either a selector string or a rule object.
+
+Returns:
+  - An error if the value is neither a string nor a valid rule object.
+*/
+func (r *FieldRule) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '"' {
+		var selector string
+		if err := json.Unmarshal(trimmed, &selector); err != nil {
+			return fmt.Errorf("invalid field rule: %v", err)
+		}
+		*r = FieldRule{Selector: selector}
+		return nil
+	}
+
+	// Decode through an alias type so this method is not invoked recursively.
+	type fieldRule FieldRule
+	var rule fieldRule
+	if err := json.Unmarshal(data, &rule); err != nil {
+		return fmt.Errorf("invalid field rule: %v", err)
+	}
+	*r = FieldRule(rule)
+	return nil
+}
+
+/*
+FieldRules returns every extraction rule configured under ParseRules, keyed by field name.
+
+Returns:
+  - A new map combining ParseRules.Fields with the legacy shorthand keys ("title",
+    "metaDescription", "articleContent", "author", "datePublished").
+
+Notes:
+  - Empty shorthand keys are skipped. Shorthand rules fall back to the matching
+    OpenGraph, Twitter Card and schema.org properties (e.g. "author" to "jsonld:*.author.name").
+    "datePublished" is normalized to a UTC time with the "toDate" transform, and
+    "articleContent" falls back to the page's main content (ReadabilityReference) and is
+    converted to DataFormatting.ContentFormat.
+  - An entry in ParseRules.Fields takes precedence over a shorthand key of the same name.
+*/
+func (cfg *Config) FieldRules() map[string]FieldRule {
+	rules := make(map[string]FieldRule, len(cfg.ParseRules.Fields)+5)
+	shorthand := []struct {
+		name     string
+		selector string
+	}{
+		{"title", cfg.ParseRules.Title},
+		{"metaDescription", cfg.ParseRules.MetaDescription},
+		{"articleContent", cfg.ParseRules.ArticleContent},
+		{"author", cfg.ParseRules.Author},
+		{"datePublished", cfg.ParseRules.DatePublished},
+	}
+	for _, s := range shorthand {
+		if s.selector != "" {
+			rules[s.name] = FieldRule{Selector: s.selector, Fallback: shorthandFallbacks[s.name], Transforms: shorthandTransforms[s.name]}
+		}
+	}
+	if rule, ok := rules["articleContent"]; ok {
+		rule.Transforms = contentFormatTransforms[cfg.DataFormatting.ContentFormat]
+		rules["articleContent"] = rule
+	}
+	for name, rule := range cfg.ParseRules.Fields {
+		rules[name] = rule
+	}
+	return rules
+}
+
+/*
+FieldNames returns the names of the configured fields in a stable order.
+
+Returns:
+  - The shorthand keys that are set, in declaration order ("title", "metaDescription",
+    "articleContent", "author", "datePublished"), then the names in ParseRules.Fields
+    and then those in ParseRules.Items.Fields, each sorted, without duplicates.
+
+Usage:
+
+	Output formats with fixed columns (e.g. CSV) use this order for their columns.
+*/
+func (cfg *Config) FieldNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	rules := cfg.FieldRules()
+	for _, name := range []string{"title", "metaDescription", "articleContent", "author", "datePublished"} {
+		if _, ok := rules[name]; ok {
+			add(name)
+		}
+	}
+	for _, name := range sortedNames(cfg.ParseRules.Fields) {
+		add(name)
+	}
+	if cfg.ParseRules.Items != nil {
+		for _, name := range sortedNames(cfg.ParseRules.Items.Fields) {
+			add(name)
+		}
+	}
+	return names
+}
+
+// sortedNames returns the keys of fields in sorted order.
+func sortedNames(fields map[string]FieldRule) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*
+Target returns the selector to evaluate and the attribute to read, expanding the
+"@name" shorthand of CSS selectors.
+
+Returns:
+  - The selector with any attribute shorthand removed.
+  - The attribute to read; an explicit Attribute takes precedence over the shorthand.
+*/
+func (r FieldRule) Target() (string, string) {
+	selector, attribute := strings.TrimSpace(r.Selector), r.Attribute
+	if r.Type == SelectorXPath {
+		return selector, attribute
+	}
+	if m := attrSuffix.FindStringSubmatch(selector); m != nil {
+		selector = m[1]
+		if attribute == "" {
+			attribute = m[2]
+		}
+	}
+	return selector, attribute
+}
+
+/*
+ParseReference splits a structured-data reference into its source and key.
+
+Parameters:
+  - ref: A reference such as "jsonld:Article.author.name", "microdata:Product.offers.price",
+    "rdfa:Person.name", "og:title", "twitter:card" or "readability".
+
+Returns:
+  - The source: "jsonld", "microdata", "rdfa", "readability", or the meta property prefix
+    ("og", "twitter", ...).
+  - The key: "Type.path" for item sources ("*" matches any type), the full meta
+    property name (e.g. "og:title") for meta sources, or "" for "readability".
+  - An error if the source is unknown or the key is empty.
+*/
+func ParseReference(ref string) (string, string, error) {
+	if strings.TrimSpace(ref) == ReadabilityReference {
+		return ReadabilityReference, "", nil
+	}
+	source, key, ok := strings.Cut(strings.TrimSpace(ref), ":")
+	if !ok || !referenceSources[source] {
+		return "", "", fmt.Errorf("unknown structured-data reference %q", ref)
+	}
+	switch source {
+	case "jsonld", "microdata", "rdfa":
+		if itemType, _, _ := strings.Cut(key, "."); itemType == "" {
+			return "", "", fmt.Errorf("structured-data reference %q has no type", ref)
+		}
+		return source, key, nil
+	}
+	if key == "" {
+		return "", "", fmt.Errorf("structured-data reference %q has no property", ref)
+	}
+	return source, source + ":" + key, nil
+}
+
+/*
+ValidateRules checks that every rule returned by FieldRules, including nested rules,
+has a known selector type, a selector that compiles, and well-formed Fallback references,
+and that DataFormatting.ContentFormat is known. ParseRules.Items, when set, must have a
+selector and nested Fields, which are validated the same way.
+
+Returns:
+  - An error naming the first invalid field (in name order), or nil if all rules are valid.
+
+Usage:
+
+	if err := cfg.ValidateRules(); err != nil {
+	    // Handle error
+	}
+*/
+func (cfg *Config) ValidateRules() error {
+	if _, ok := contentFormatTransforms[cfg.DataFormatting.ContentFormat]; !ok {
+		return fmt.Errorf("unknown content format %q (want %q, %q or %q)", cfg.DataFormatting.ContentFormat, ContentHTML, ContentMarkdown, ContentText)
+	}
+	if items := cfg.ParseRules.Items; items != nil {
+		if err := validateItemsRule(*items); err != nil {
+			return fmt.Errorf("items: %v", err)
+		}
+		if err := validateRules(map[string]FieldRule{"items": *items}, "", false); err != nil {
+			return err
+		}
+	}
+	return validateRules(cfg.FieldRules(), "", false)
+}
+
+// validateItemsRule checks that the Items rule selects elements and has fields to extract
+// from each of them.
+func validateItemsRule(rule FieldRule) error {
+	_, attribute := rule.Target()
+	switch {
+	case rule.Selector == "":
+		return fmt.Errorf("no selector")
+	case len(rule.Fields) == 0:
+		return fmt.Errorf("no fields")
+	case rule.Type == RuleTable:
+		return fmt.Errorf("cannot be a table rule")
+	case attribute != "":
+		return fmt.Errorf("cannot read an attribute")
+	case rule.Default != "" || len(rule.Fallback) > 0 || len(rule.Transforms) > 0:
+		return fmt.Errorf("cannot have a default, fallback or transforms")
+	}
+	return nil
+}
+
+// validateRules checks rules recursively; prefix qualifies nested field names in errors.
+func validateRules(rules map[string]FieldRule, prefix string, nested bool) error {
+	for _, name := range sortedNames(rules) {
+		rule := rules[name]
+		field := prefix + name
+		selector, _ := rule.Target()
+		kind := rule.Type
+		if kind == "" {
+			kind = SelectorCSS
+		}
+		var err error
+		switch kind {
+		case SelectorCSS, RuleTable:
+			if selector != "" {
+				_, err = cascadia.Compile(selector)
+			}
+		case SelectorXPath:
+			if selector != "" {
+				_, err = xpath.Compile(selector)
+			}
+		default:
+			return fmt.Errorf("field %s has unknown selector type %q", field, rule.Type)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid %s selector for %s %q: %v", kind, field, rule.Selector, err)
+		}
+		if selector == "" && !nested && len(rule.Fallback) == 0 {
+			return fmt.Errorf("field %s has no selector", field)
+		}
+		if kind == RuleTable {
+			if err := validateTableRule(rule); err != nil {
+				return fmt.Errorf("field %s: %v", field, err)
+			}
+		}
+		for _, ref := range rule.Fallback {
+			if _, _, err := ParseReference(ref); err != nil {
+				return fmt.Errorf("invalid fallback for %s: %v", field, err)
+			}
+		}
+		if err := validateRules(rule.Fields, field+".", true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateTableRule rejects options that have no meaning for a table rule, whose values
+// are rows of cell text rather than a single extracted string.
+func validateTableRule(rule FieldRule) error {
+	_, attribute := rule.Target()
+	switch {
+	case attribute != "":
+		return fmt.Errorf("table rules cannot read an attribute")
+	case rule.Default != "":
+		return fmt.Errorf("table rules cannot have a default")
+	case len(rule.Fallback) > 0:
+		return fmt.Errorf("table rules cannot have a fallback")
+	case len(rule.Transforms) > 0:
+		return fmt.Errorf("table rules cannot have transforms")
+	case len(rule.Fields) > 0:
+		return fmt.Errorf("table rules cannot have nested fields")
+	}
+	return nil
+}
@@ -0,0 +1,379 @@
+// File: pkg/config/rules_test.go
+
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestFieldRuleUnmarshalJSON verifies that rules decode from both selector strings and objects.
+func TestFieldRuleUnmarshalJSON(t *testing.T) {
+	cases := []struct {
+		desc      string
+		input     string
+		expected  FieldRule
+		expectErr bool
+	}{
+		{
+			desc:     "Bare selector string",
+			input:    `".price"`,
+			expected: FieldRule{Selector: ".price"},
+		},
+		{
+			desc:  "Full rule object",
+			input: `{"selector": "img", "attribute": "src", "multiple": true, "required": true, "default": "none"}`,
+			expected: FieldRule{
+				Selector:  "img",
+				Attribute: "src",
+				Multiple:  true,
+				Required:  true,
+				Default:   "none",
+			},
+		},
+		{
+			desc:  "Nested fields accept shorthand rules",
+			input: `{"selector": ".review", "multiple": true, "fields": {"rating": "@data-rating", "body": {"selector": "p"}}}`,
+			expected: FieldRule{
+				Selector: ".review",
+				Multiple: true,
+				Fields: map[string]FieldRule{
+					"rating": {Selector: "@data-rating"},
+					"body":   {Selector: "p"},
+				},
+			},
+		},
+		{
+			desc:  "Transforms accept names and objects",
+			input: `{"selector": "h1", "transforms": ["stripHTML", {"name": "trim"}]}`,
+			expected: FieldRule{
+				Selector:   "h1",
+				Transforms: []TransformSpec{{Name: "stripHTML"}, {Name: "trim"}},
+			},
+		},
+		{
+			desc:  "Transforms carry parameters",
+			input: `{"selector": ".price", "transforms": [{"name": "regex", "pattern": "([0-9.]+)", "group": 1}, {"name": "toDate", "layouts": ["RFC3339"]}]}`,
+			expected: FieldRule{
+				Selector: ".price",
+				Transforms: []TransformSpec{
+					{Name: "regex", Pattern: "([0-9.]+)", Group: 1},
+					{Name: "toDate", Layouts: []string{"RFC3339"}},
+				},
+			},
+		},
+		{
+			desc:      "Invalid transform",
+			input:     `{"selector": "h1", "transforms": [42]}`,
+			expectErr: true,
+		},
+		{
+			desc:      "Invalid type",
+			input:     `42`,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var rule FieldRule
+			err := json.Unmarshal([]byte(tc.input), &rule)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("Expected error, got %+v", rule)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(rule, tc.expected) {
+				t.Errorf("Expected %+v, got %+v", tc.expected, rule)
+			}
+		})
+	}
+}
+
+// TestFieldRules verifies that shorthand keys and Fields are merged, with Fields taking precedence.
+func TestFieldRules(t *testing.T) {
+	var cfg Config
+	content := `{
+		"parseRules": {
+			"title": "h1",
+			"author": ".byline",
+			"fields": {
+				"title": {"selector": "title", "required": true},
+				"price": ".price"
+			}
+		}
+	}`
+	if err := json.Unmarshal([]byte(content), &cfg); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]FieldRule{
+		"title":  {Selector: "title", Required: true},
+		"author": {Selector: ".byline", Fallback: shorthandFallbacks["author"]},
+		"price":  {Selector: ".price"},
+	}
+	if rules := cfg.FieldRules(); !reflect.DeepEqual(rules, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, rules)
+	}
+}
+
+// TestFieldNames verifies the column order: shorthand keys, then Fields, then Items.Fields.
+func TestFieldNames(t *testing.T) {
+	var cfg Config
+	content := `{
+		"parseRules": {
+			"datePublished": "time",
+			"title": "h1",
+			"fields": {"sku": ".sku", "price": ".price", "title": "title"},
+			"items": {"selector": ".product", "fields": {"name": "h2", "price": ".cost"}}
+		}
+	}`
+	if err := json.Unmarshal([]byte(content), &cfg); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{"title", "datePublished", "price", "sku", "name"}
+	if names := cfg.FieldNames(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+}
+
+// TestContentFormat verifies that articleContent is converted to DataFormatting.ContentFormat
+// and that unknown formats are rejected.
+func TestContentFormat(t *testing.T) {
+	cases := []struct {
+		format     string
+		transforms []TransformSpec
+		expectErr  bool
+	}{
+		{format: "", transforms: nil},
+		{format: ContentHTML, transforms: nil},
+		{format: ContentMarkdown, transforms: []TransformSpec{{Name: "toMarkdown"}}},
+		{format: ContentText, transforms: []TransformSpec{{Name: "stripHTML"}}},
+		{format: "pdf", expectErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.format, func(t *testing.T) {
+			var cfg Config
+			cfg.ParseRules.ArticleContent = "article"
+			cfg.DataFormatting.ContentFormat = tc.format
+			err := cfg.ValidateRules()
+			if tc.expectErr {
+				if err == nil || !strings.Contains(err.Error(), "unknown content format") {
+					t.Errorf("Expected unknown content format error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			rule := cfg.FieldRules()["articleContent"]
+			if !reflect.DeepEqual(rule.Transforms, tc.transforms) {
+				t.Errorf("Expected transforms %+v, got %+v", tc.transforms, rule.Transforms)
+			}
+			if fallback := rule.Fallback; len(fallback) == 0 || fallback[len(fallback)-1] != ReadabilityReference {
+				t.Errorf("Expected readability as the last fallback, got %v", fallback)
+			}
+		})
+	}
+}
+
+// TestValidateItems verifies the checks applied to ParseRules.Items.
+func TestValidateItems(t *testing.T) {
+	name := map[string]FieldRule{"name": {Selector: "h2"}}
+	cases := []struct {
+		desc      string
+		items     FieldRule
+		expectErr string
+	}{
+		{desc: "Valid items", items: FieldRule{Selector: ".card", Fields: map[string]FieldRule{"name": {Selector: "h2"}, "id": {Selector: "@data-id"}}}},
+		{desc: "XPath items", items: FieldRule{Selector: "//div[@class='card']", Type: SelectorXPath, Fields: name}},
+		{desc: "Missing selector", items: FieldRule{Fields: name}, expectErr: "items: no selector"},
+		{desc: "Missing fields", items: FieldRule{Selector: ".card"}, expectErr: "items: no fields"},
+		{desc: "Table items", items: FieldRule{Selector: "table", Type: RuleTable, Fields: name}, expectErr: "items: cannot be a table rule"},
+		{desc: "Invalid selector", items: FieldRule{Selector: ".card[", Fields: name}, expectErr: "invalid css selector for items"},
+		{desc: "Invalid nested selector", items: FieldRule{Selector: ".card", Fields: map[string]FieldRule{"name": {Selector: "h2["}}}, expectErr: "invalid css selector for items.name"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var cfg Config
+			items := tc.items
+			cfg.ParseRules.Items = &items
+			err := cfg.ValidateRules()
+			if tc.expectErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectErr) {
+				t.Errorf("Expected error containing %q, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+// TestFieldRuleTarget verifies that the "@name" shorthand is expanded for CSS selectors only.
+func TestFieldRuleTarget(t *testing.T) {
+	cases := []struct {
+		desc      string
+		rule      FieldRule
+		selector  string
+		attribute string
+	}{
+		{"Plain selector", FieldRule{Selector: " .price "}, ".price", ""},
+		{"Attribute shorthand", FieldRule{Selector: "a.next@href"}, "a.next", "href"},
+		{"Shorthand on the element itself", FieldRule{Selector: "@data-id"}, "", "data-id"},
+		{"Explicit attribute wins", FieldRule{Selector: "img@alt", Attribute: "src"}, "img", "src"},
+		{"Attribute selector is not shorthand", FieldRule{Selector: "a[href]"}, "a[href]", ""},
+		{"XPath is left untouched", FieldRule{Selector: "//a/@href", Type: SelectorXPath}, "//a/@href", ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			selector, attribute := tc.rule.Target()
+			if selector != tc.selector || attribute != tc.attribute {
+				t.Errorf("Expected (%q, %q), got (%q, %q)", tc.selector, tc.attribute, selector, attribute)
+			}
+		})
+	}
+}
+
+// TestValidateRules verifies that selector types and expressions are checked, including nested rules.
+func TestValidateRules(t *testing.T) {
+	cases := []struct {
+		desc      string
+		fields    map[string]FieldRule
+		expectErr string
+	}{
+		{
+			desc: "Valid CSS and XPath rules",
+			fields: map[string]FieldRule{
+				"price":  {Selector: ".price@data-value"},
+				"byline": {Selector: "//p[starts-with(., 'By ')]", Type: SelectorXPath},
+				"items": {
+					Selector: ".item",
+					Fields:   map[string]FieldRule{"id": {Selector: "@data-id"}},
+				},
+			},
+		},
+		{
+			desc:      "Missing selector",
+			fields:    map[string]FieldRule{"price": {}},
+			expectErr: "field price has no selector",
+		},
+		{
+			desc:   "Fallback without selector",
+			fields: map[string]FieldRule{"sku": {Fallback: []string{"jsonld:Product.sku"}}},
+		},
+		{
+			desc:      "Invalid fallback reference",
+			fields:    map[string]FieldRule{"sku": {Selector: ".sku", Fallback: []string{"sku"}}},
+			expectErr: "invalid fallback for sku",
+		},
+		{
+			desc:      "Unknown type",
+			fields:    map[string]FieldRule{"price": {Selector: ".price", Type: "regex"}},
+			expectErr: `field price has unknown selector type "regex"`,
+		},
+		{
+			desc:      "Invalid CSS selector",
+			fields:    map[string]FieldRule{"price": {Selector: "div[unclosed"}},
+			expectErr: "invalid css selector for price",
+		},
+		{
+			desc:   "Table rule",
+			fields: map[string]FieldRule{"prices": {Selector: "table.pricing", Type: RuleTable, Multiple: true}},
+		},
+		{
+			desc:      "Table rule with an attribute",
+			fields:    map[string]FieldRule{"prices": {Selector: "table@id", Type: RuleTable}},
+			expectErr: "field prices: table rules cannot read an attribute",
+		},
+		{
+			desc:      "Table rule with transforms",
+			fields:    map[string]FieldRule{"prices": {Selector: "table", Type: RuleTable, Transforms: []TransformSpec{{Name: "lower"}}}},
+			expectErr: "field prices: table rules cannot have transforms",
+		},
+		{
+			desc:      "Invalid table selector",
+			fields:    map[string]FieldRule{"prices": {Selector: "table[", Type: RuleTable}},
+			expectErr: "invalid table selector for prices",
+		},
+		{
+			desc: "Invalid nested XPath expression",
+			fields: map[string]FieldRule{
+				"items": {
+					Selector: ".item",
+					Fields:   map[string]FieldRule{"id": {Selector: "//span[", Type: SelectorXPath}},
+				},
+			},
+			expectErr: "invalid xpath selector for items.id",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var cfg Config
+			cfg.ParseRules.Fields = tc.fields
+			err := cfg.ValidateRules()
+			if tc.expectErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectErr) {
+				t.Errorf("Expected error containing %q, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+// TestParseReference verifies that structured-data references are split into source and key.
+func TestParseReference(t *testing.T) {
+	cases := []struct {
+		ref       string
+		source    string
+		key       string
+		expectErr bool
+	}{
+		{ref: "jsonld:Article.author.name", source: "jsonld", key: "Article.author.name"},
+		{ref: "microdata:*.price", source: "microdata", key: "*.price"},
+		{ref: "rdfa:Person", source: "rdfa", key: "Person"},
+		{ref: "og:title", source: "og", key: "og:title"},
+		{ref: " twitter:card ", source: "twitter", key: "twitter:card"},
+		{ref: "article:published_time", source: "article", key: "article:published_time"},
+		{ref: "readability", source: "readability", key: ""},
+		{ref: "jsonld:.name", expectErr: true},
+		{ref: "og:", expectErr: true},
+		{ref: "css:title", expectErr: true},
+		{ref: "title", expectErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.ref, func(t *testing.T) {
+			source, key, err := ParseReference(tc.ref)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("Expected error, got (%q, %q)", source, key)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if source != tc.source || key != tc.key {
+				t.Errorf("Expected (%q, %q), got (%q, %q)", tc.source, tc.key, source, key)
+			}
+		})
+	}
+}
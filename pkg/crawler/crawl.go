@@ -0,0 +1,306 @@
+// File: pkg/crawler/crawl.go
+
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+Page is the result of fetching a single URL during a crawl.
+
+Fields:
+  - URL: The normalized URL that was fetched.
+  - Depth: Number of links followed from a seed URL to reach this page (seeds are depth 0).
+  - Content: The response body; empty if the fetch failed.
+  - Err: The fetch error, if any.
+  - FetchedAt: When the fetch completed; relative dates on the page are resolved against it.
+  - PageNumber: The page's position in a pagination chain (the seed is 1), or 0 if the
+    page was not reached through pagination.
+*/
+type Page struct {
+	URL        string
+	Depth      int
+	Content    string
+	Err        error
+	FetchedAt  time.Time
+	PageNumber int
+}
+
+/*
+Seeds returns the normalized starting URLs for a crawl.
+
+Returns:
+  - The base URL (when URL.IncludeBase is set), each literal entry of URL.Routes
+    resolved against the base URL, and the static prefix of each wildcard route
+    (e.g. "/blog/" for "/blog/*", the site root for "*"), without duplicates.
+  - An error if the base URL is not an absolute http(s) URL.
+*/
+func (c *Crawler) Seeds() ([]string, error) {
+	base, err := c.baseURL()
+	if err != nil {
+		return nil, err
+	}
+
+	var seeds []string
+	seen := make(map[string]bool)
+	add := func(u *url.URL) {
+		n := NormalizeURL(u)
+		if !seen[n] {
+			seen[n] = true
+			seeds = append(seeds, n)
+		}
+	}
+
+	if c.includeBase {
+		add(base)
+	}
+	for _, route := range c.routes {
+		route = strings.TrimSpace(route)
+		if route == "" {
+			continue
+		}
+		ref, err := url.Parse(route)
+		if err != nil {
+			return nil, fmt.Errorf("invalid route %q: %v", route, err)
+		}
+		add(base.ResolveReference(ref))
+	}
+	for _, p := range c.patterns {
+		add(base.ResolveReference(&url.URL{Path: p.prefix}))
+	}
+	return seeds, nil
+}
+
+/*
+Crawl fetches the seed URLs and follows links breadth-first up to ScrapingOptions.MaxDepth.
+
+Parameters:
+  - ctx: Cancelling ctx stops scheduling new URLs; in-flight requests are aborted and
+    drained before Crawl returns.
+  - visit: Called once per fetched page. Calls are never concurrent, so visit may
+    append to shared state without locking. Pages that failed to fetch are passed
+    with Err set and are not expanded further.
+
+Returns:
+  - An error if the seed URLs could not be determined, or ctx.Err() if the crawl was
+    cancelled; per-page failures are reported through visit.
+
+Usage:
+
+	err := c.Crawl(ctx, func(p crawler.Page) {
+	    if p.Err != nil {
+	        // Handle fetch error.
+	        return
+	    }
+	    // Parse p.Content.
+	})
+
+Notes:
+  - Up to ScrapingOptions.Concurrency pages are fetched in parallel, with at most
+    ScrapingOptions.PerHostConcurrency requests to any one host at a time.
+  - With a concurrency of 1 pages are visited in strict breadth-first order; otherwise
+    the order within a depth level depends on response times.
+  - When URL.Sitemaps.Enabled is set, pages listed in the site's sitemaps (see
+    SitemapURLs) are added to the seeds at depth 0. Sitemaps that could not be fetched
+    or parsed are passed to visit first, with Err set to a *SitemapError.
+  - Only links on the same host as URL.Base are followed. When URL.Routes contains
+    wildcard patterns ("*", "/blog/*", "/products/**"), a link is only followed if
+    its path matches one of them; otherwise every same-host link is followed.
+  - A visited set keyed on normalized URLs guarantees no page is fetched twice.
+  - With ScrapingOptions.Pagination set, each seed (other than sitemap pages) starts a
+    chain of "next" pages that is followed regardless of MaxDepth; pages in a chain keep
+    the seed's depth. A chain whose next page an ordinary link reached first continues
+    from that page's "next" link. See pagination.advance for when a chain stops.
+  - Unless ScrapingOptions.IgnoreRobots is set, each host's robots.txt is fetched once
+    and disallowed pages are reported with an error wrapping ErrRobotsDisallowed.
+  - Pages whose fetch was aborted by cancellation are not passed to visit.
+*/
+func (c *Crawler) Crawl(ctx context.Context, visit func(Page)) error {
+	seeds, err := c.Seeds()
+	if err != nil {
+		return err
+	}
+	routeSeeds := seeds
+	if c.sitemapsEnabled {
+		pages, err := c.SitemapURLs(ctx, func(e *SitemapError) {
+			visit(Page{URL: e.URL, Err: e})
+		})
+		if err != nil {
+			return err
+		}
+		seeds = append(seeds, pages...)
+	}
+	base, _ := c.baseURL()
+
+	type item struct {
+		url   string
+		depth int
+	}
+
+	jobs := make(chan item)
+	results := make(chan Page)
+	var wg sync.WaitGroup
+	for i := 0; i < c.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				if !c.robotsAllowed(ctx, job.url) {
+					results <- Page{URL: job.url, Depth: job.depth, Err: fmt.Errorf("%s: %w", job.url, ErrRobotsDisallowed)}
+					continue
+				}
+				content, err := c.fetchWithHostSlot(ctx, job.url)
+				results <- Page{URL: job.url, Depth: job.depth, Content: content, Err: err, FetchedAt: time.Now()}
+			}
+		}()
+	}
+
+	visited := make(map[string]bool)
+	var queue []item
+	for _, s := range seeds {
+		if visited[s] {
+			continue
+		}
+		visited[s] = true
+		queue = append(queue, item{url: s})
+	}
+
+	// chains maps a URL awaiting fetch to the pagination chain it continues; fetched holds
+	// what the chains need to know about each page already fetched.
+	chains := make(map[string]*pageChain)
+	fetched := make(map[string]*pageFacts)
+	if c.pagination != nil {
+		for _, s := range routeSeeds {
+			if ch := newChain(s); ch != nil {
+				chains[s] = ch
+			}
+		}
+	}
+
+	// The coordinator owns the queue and visited set; workers only fetch.
+	done := ctx.Done()
+	inFlight := 0
+	for len(queue) > 0 || inFlight > 0 {
+		var send chan item
+		var next item
+		if len(queue) > 0 {
+			send = jobs
+			next = queue[0]
+		}
+
+		select {
+		case send <- next:
+			queue = queue[1:]
+			inFlight++
+		case page := <-results:
+			inFlight--
+			if ctx.Err() != nil && errors.Is(page.Err, ctx.Err()) {
+				continue
+			}
+			var facts *pageFacts
+			if c.pagination != nil {
+				facts = c.pagination.inspect(page)
+			}
+			fetched[page.URL] = facts
+			ch := chains[page.URL]
+			if ch != nil {
+				delete(chains, page.URL)
+				page.PageNumber = ch.page
+			}
+			visit(page)
+			for ch != nil && ctx.Err() == nil {
+				next, ok := c.pagination.advance(ch, facts, base)
+				if !ok || chains[next] != nil {
+					break
+				}
+				if done, ok := fetched[next]; ok {
+					// An ordinary link got to the next page first; carry on from its facts.
+					facts = done
+					continue
+				}
+				// A next page already queued through an ordinary link joins the chain as is.
+				chains[next] = ch
+				if !visited[next] {
+					visited[next] = true
+					queue = append(queue, item{url: next, depth: page.Depth})
+				}
+				break
+			}
+			if page.Err != nil || page.Depth >= c.maxDepth || ctx.Err() != nil {
+				continue
+			}
+			pageURL, err := url.Parse(page.URL)
+			if err != nil {
+				continue
+			}
+			for _, link := range ExtractLinks(pageURL, page.Content) {
+				if visited[link] || !sameHost(base, link) || !c.followable(link) {
+					continue
+				}
+				visited[link] = true
+				queue = append(queue, item{url: link, depth: page.Depth + 1})
+			}
+		case <-done:
+			// Stop scheduling; keep looping only to drain in-flight results.
+			queue = nil
+			done = nil
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+	return ctx.Err()
+}
+
+// fetchWithHostSlot fetches rawURL while holding one of its host's concurrency slots.
+func (c *Crawler) fetchWithHostSlot(ctx context.Context, rawURL string) (string, error) {
+	slot := c.hostSlot(hostOf(rawURL))
+	select {
+	case slot <- struct{}{}:
+		defer func() { <-slot }()
+	case <-ctx.Done():
+		return "", &FetchError{URL: rawURL, Err: ctx.Err()}
+	}
+	return c.FetchURLContext(ctx, rawURL)
+}
+
+// hostSlot returns the semaphore channel limiting concurrent requests to host.
+func (c *Crawler) hostSlot(host string) chan struct{} {
+	host = strings.ToLower(host)
+	c.slotsMu.Lock()
+	defer c.slotsMu.Unlock()
+	slot, ok := c.hostSlots[host]
+	if !ok {
+		slot = make(chan struct{}, c.perHostConcurrency)
+		c.hostSlots[host] = slot
+	}
+	return slot
+}
+
+// baseURL parses and validates the configured base URL.
+func (c *Crawler) baseURL() (*url.URL, error) {
+	base, err := url.Parse(c.base)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL %q: %v", c.base, err)
+	}
+	if (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: must be an absolute http(s) URL", c.base)
+	}
+	return base, nil
+}
+
+// sameHost reports whether link points at the same host as base.
+func sameHost(base *url.URL, link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Hostname(), base.Hostname())
+}
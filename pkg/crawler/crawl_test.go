@@ -0,0 +1,496 @@
+// File: pkg/crawler/crawl_test.go
+
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+)
+
+// newSiteServer serves a small linked site:
+//
+//	/      -> /a, /b
+//	/a     -> /a/deep, / (back link), external link
+//	/b     -> /a (already queued), /missing
+//	/a/deep -> /a/deeper
+//
+// It records how many times each path was requested.
+func newSiteServer() (*httptest.Server, map[string]int, *sync.Mutex) {
+	pages := map[string]string{
+		"/":       `<a href="/a">a</a><a href="/b#frag">b</a>`,
+		"/a":      `<a href="a/deep">deep</a><a href="/">home</a><a href="https://elsewhere.example/">ext</a>`,
+		"/b":      `<a href="/a">a again</a><a href="/missing">missing</a>`,
+		"/a/deep": `<a href="/a/deeper">deeper</a>`,
+	}
+	hits := make(map[string]int)
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	return srv, hits, &mu
+}
+
+// newCrawlCrawler builds a single-worker Crawler pointed at base with the given
+// routes and depth, so visit order is deterministic.
+func newCrawlCrawler(base string, routes []string, includeBase bool, depth int) *Crawler {
+	cfg := &config.Config{}
+	cfg.URL.Base = base
+	cfg.URL.Routes = routes
+	cfg.URL.IncludeBase = includeBase
+	cfg.ScrapingOptions.MaxDepth = depth
+	cfg.ScrapingOptions.Concurrency = 1
+	cfg.ApplyDefaults()
+	cfg.ScrapingOptions.RetryAttempts = 0
+	cfg.ScrapingOptions.RateLimit = 0
+	return New(cfg)
+}
+
+// TestSeeds verifies seed construction from the base URL and routes.
+func TestSeeds(t *testing.T) {
+	cases := []struct {
+		desc        string
+		base        string
+		routes      []string
+		includeBase bool
+		expected    []string
+		expectErr   bool
+	}{
+		{
+			desc:     "Routes only",
+			base:     "https://example.com",
+			routes:   []string{"/route1", "route2"},
+			expected: []string{"https://example.com/route1", "https://example.com/route2"},
+		},
+		{
+			desc:        "Include base and skip duplicates",
+			base:        "https://example.com",
+			routes:      []string{"/", "/x", "/x#dup"},
+			includeBase: true,
+			expected:    []string{"https://example.com/", "https://example.com/x"},
+		},
+		{
+			desc:     "Wildcard routes seed their static prefix",
+			base:     "https://example.com",
+			routes:   []string{"/about", "/blog/*", "/products/**", "*"},
+			expected: []string{"https://example.com/about", "https://example.com/blog/", "https://example.com/products/", "https://example.com/"},
+		},
+		{
+			desc:      "Relative base URL is rejected",
+			base:      "example.com",
+			routes:    []string{"/"},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := newCrawlCrawler(tc.base, tc.routes, tc.includeBase, 1)
+			seeds, err := c.Seeds()
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("Expected error, got seeds %v", seeds)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(seeds, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, seeds)
+			}
+		})
+	}
+}
+
+// TestCrawl verifies breadth-first order, depth limiting, host scoping and that
+// no page is fetched twice.
+func TestCrawl(t *testing.T) {
+	srv, hits, mu := newSiteServer()
+	defer srv.Close()
+
+	cases := []struct {
+		desc     string
+		depth    int
+		expected []string
+		failed   []string
+	}{
+		{
+			desc:     "Depth 1 visits seed and its direct links",
+			depth:    1,
+			expected: []string{"/", "/a", "/b"},
+		},
+		{
+			desc:     "Depth 2 follows one more level breadth-first",
+			depth:    2,
+			expected: []string{"/", "/a", "/b", "/a/deep", "/missing"},
+			failed:   []string{"/missing"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			mu.Lock()
+			for k := range hits {
+				delete(hits, k)
+			}
+			mu.Unlock()
+
+			c := newCrawlCrawler(srv.URL, []string{"/"}, false, tc.depth)
+			var visited, failed []string
+			err := c.Crawl(context.Background(), func(p Page) {
+				path := p.URL[len(srv.URL):]
+				visited = append(visited, path)
+				if p.Err != nil {
+					failed = append(failed, path)
+				}
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(visited, tc.expected) {
+				t.Errorf("Expected visit order %v, got %v", tc.expected, visited)
+			}
+			if !reflect.DeepEqual(failed, tc.failed) {
+				t.Errorf("Expected failures %v, got %v", tc.failed, failed)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for path, n := range hits {
+				if n != 1 {
+					t.Errorf("Expected %s to be fetched once, got %d", path, n)
+				}
+			}
+		})
+	}
+
+	c := newCrawlCrawler("::bad", nil, true, 1)
+	if err := c.Crawl(context.Background(), func(Page) {}); err == nil {
+		t.Error("Expected error for invalid base URL")
+	}
+}
+
+// TestCrawlWildcardRoutes verifies that wildcard routes restrict which discovered
+// links are followed while literal routes are still fetched directly.
+func TestCrawlWildcardRoutes(t *testing.T) {
+	pages := map[string]string{
+		"/blog/":         `<a href="/blog/one">1</a><a href="/blog/2024/two">2</a><a href="/shop">s</a>`,
+		"/blog/one":      `<a href="/blog/three">3</a>`,
+		"/about":         `<a href="/team">t</a>`,
+		"/blog/three":    ``,
+		"/blog/2024/two": ``,
+		"/shop":          ``,
+		"/team":          ``,
+		"/products/":     `<a href="/products/a/b">ab</a>`,
+		"/products/a/b":  ``,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, pages[r.URL.Path])
+	}))
+	defer srv.Close()
+
+	c := newCrawlCrawler(srv.URL, []string{"/about", "/blog/*", "/products/**"}, false, 3)
+	var visited []string
+	if err := c.Crawl(context.Background(), func(p Page) { visited = append(visited, p.URL[len(srv.URL):]) }); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{"/about", "/blog/", "/products/", "/blog/one", "/products/a/b", "/blog/three"}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("Expected %v, got %v", expected, visited)
+	}
+}
+
+// TestCrawlConcurrent verifies that the worker pool fetches in parallel while never
+// exceeding the per-host concurrency cap.
+func TestCrawlConcurrent(t *testing.T) {
+	var active, peak int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		if r.URL.Path == "/" {
+			for i := 0; i < 8; i++ {
+				fmt.Fprintf(w, `<a href="/p%d">p</a>`, i)
+			}
+		}
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.URL.Base = srv.URL
+	cfg.ScrapingOptions.MaxDepth = 1
+	cfg.ScrapingOptions.Concurrency = 6
+	cfg.ScrapingOptions.PerHostConcurrency = 3
+	cfg.ApplyDefaults()
+	cfg.ScrapingOptions.RateLimit = 0
+	c := New(cfg)
+
+	var visited []string
+	if err := c.Crawl(context.Background(), func(p Page) { visited = append(visited, p.URL) }); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(visited) != 9 {
+		t.Errorf("Expected 9 pages visited, got %d: %v", len(visited), visited)
+	}
+	if p := atomic.LoadInt32(&peak); p > 3 {
+		t.Errorf("Expected at most 3 concurrent requests to the host, saw %d", p)
+	} else if p < 2 {
+		t.Errorf("Expected requests to run in parallel, peak was %d", p)
+	}
+}
+
+// TestCrawlCancel verifies that cancelling the context stops the crawl, drains
+// in-flight requests and still reports pages completed before cancellation.
+func TestCrawlCancel(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path == "/" {
+			for i := 0; i < 20; i++ {
+				fmt.Fprintf(w, `<a href="/p%d">p</a>`, i)
+			}
+			return
+		}
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	cfg := &config.Config{}
+	cfg.URL.Base = srv.URL
+	cfg.ScrapingOptions.MaxDepth = 1
+	cfg.ScrapingOptions.Concurrency = 4
+	cfg.ScrapingOptions.PerHostConcurrency = 4
+	cfg.ApplyDefaults()
+	cfg.ScrapingOptions.RateLimit = 0
+	c := New(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var visited []Page
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.Crawl(ctx, func(p Page) {
+			visited = append(visited, p)
+			if p.Depth == 0 {
+				// Give workers a moment to pick up blocked requests, then cancel.
+				time.AfterFunc(30*time.Millisecond, cancel)
+			}
+		})
+	}()
+
+	select {
+	case err := <-errCh:
+		if err != context.Canceled {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Crawl did not return after cancellation")
+	}
+	if len(visited) != 1 || visited[0].Err != nil {
+		t.Errorf("Expected only the completed seed page to be visited, got %+v", visited)
+	}
+}
+
+// TestCrawlPagination verifies that pagination chains are followed beyond MaxDepth and
+// stop at the last page, the page limit, loops, empty pages and repeated content.
+func TestCrawlPagination(t *testing.T) {
+	pages := map[string]string{
+		"/list":         `<div class="item">1</div><a rel="next" href="/list?page=2">next</a>`,
+		"/list?page=2":  `<div class="item">2</div><a rel="next" href="?page=3">next</a>`,
+		"/list?page=3":  `<div class="item">3</div>`,
+		"/loop":         `<div class="item">1</div><a rel="next" href="/loop?p=2">next</a>`,
+		"/loop?p=2":     `<div class="item">2</div><a rel="next" href="/loop">next</a>`,
+		"/tpl":          `<div class="item">1</div>`,
+		"/tpl?page=2":   `<div class="item">2</div>`,
+		"/tpl?page=3":   `<p>No results</p>`,
+		"/same":         `<div class="item">1</div>`,
+		"/off":          `<div class="item">1</div><a rel="next" href="https://elsewhere.example/list?page=2">next</a>`,
+		"/blank":        `<script>load()</script>`,
+		"/blank?page=2": `<div class="item">2</div>`,
+		"/robots.txt":   ``,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if body, ok := pages[r.URL.RequestURI()]; ok {
+			fmt.Fprint(w, body)
+			return
+		}
+		if r.URL.Path == "/same" {
+			fmt.Fprint(w, `<div class="item">last</div>`)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	cases := []struct {
+		desc     string
+		route    string
+		next     string
+		template string
+		maxPages int
+		items    string
+		expected []string
+	}{
+		{
+			desc:     "Next links until the last page",
+			route:    "/list",
+			next:     "a[rel=next]",
+			expected: []string{"/list", "/list?page=2", "/list?page=3"},
+		},
+		{
+			desc:     "Max pages",
+			route:    "/list",
+			next:     "a[rel=next]",
+			maxPages: 2,
+			expected: []string{"/list", "/list?page=2"},
+		},
+		{
+			desc:     "Repeated URL ends the chain",
+			route:    "/loop",
+			next:     "a[rel=next]",
+			expected: []string{"/loop", "/loop?p=2"},
+		},
+		{
+			desc:     "Off-site next link is not followed",
+			route:    "/off",
+			next:     "a[rel=next]",
+			expected: []string{"/off"},
+		},
+		{
+			desc:     "Template until a page without items",
+			route:    "/tpl",
+			template: "?page={page}",
+			items:    ".item",
+			expected: []string{"/tpl", "/tpl?page=2", "/tpl?page=3"},
+		},
+		{
+			desc:     "Template until the content repeats",
+			route:    "/same",
+			template: "/same?page={page}",
+			expected: []string{"/same", "/same?page=2", "/same?page=3"},
+		},
+		{
+			desc:     "Page without text ends the chain",
+			route:    "/blank",
+			template: "?page={page}",
+			expected: []string{"/blank"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.URL.Base = srv.URL
+			cfg.URL.Routes = []string{tc.route}
+			cfg.ScrapingOptions.Concurrency = 1
+			cfg.ScrapingOptions.Pagination.NextSelector = tc.next
+			cfg.ScrapingOptions.Pagination.URLTemplate = tc.template
+			cfg.ScrapingOptions.Pagination.MaxPages = tc.maxPages
+			if tc.items != "" {
+				cfg.ParseRules.Items = &config.FieldRule{Selector: tc.items}
+			}
+			cfg.ApplyDefaults()
+			cfg.ScrapingOptions.MaxDepth = 0
+			cfg.ScrapingOptions.RetryAttempts = 0
+			cfg.ScrapingOptions.RateLimit = 0
+			c := New(cfg)
+
+			var visited []string
+			err := c.Crawl(context.Background(), func(p Page) {
+				visited = append(visited, p.URL[len(srv.URL):])
+				if p.PageNumber != len(visited) {
+					t.Errorf("Expected %s to be page %d, got %d", p.URL, len(visited), p.PageNumber)
+				}
+				if p.Depth != 0 {
+					t.Errorf("Expected %s to keep the seed's depth, got %d", p.URL, p.Depth)
+				}
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(visited, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, visited)
+			}
+		})
+	}
+}
+
+// TestCrawlPaginationFetchedNext verifies that a chain whose next page was already fetched
+// through an ordinary link continues from that page instead of ending.
+func TestCrawlPaginationFetchedNext(t *testing.T) {
+	pages := map[string]string{
+		"/hub":         `<a href="/list?page=3">page 3</a>`,
+		"/list":        `<div class="item">1</div><a rel="next" href="?page=2">next</a>`,
+		"/list?page=2": `<div class="item">2</div><a rel="next" href="?page=3">next</a>`,
+		"/list?page=3": `<div class="item">3</div><a rel="next" href="?page=4">next</a>`,
+		"/list?page=4": `<div class="item">4</div>`,
+		"/robots.txt":  ``,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if body, ok := pages[r.URL.RequestURI()]; ok {
+			fmt.Fprint(w, body)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.URL.Base = srv.URL
+	cfg.URL.Routes = []string{"/hub", "/list"}
+	cfg.ScrapingOptions.Concurrency = 1
+	cfg.ScrapingOptions.Pagination.NextSelector = "a[rel=next]"
+	cfg.ApplyDefaults()
+	cfg.ScrapingOptions.MaxDepth = 1
+	cfg.ScrapingOptions.RetryAttempts = 0
+	cfg.ScrapingOptions.RateLimit = 0
+	c := New(cfg)
+
+	// The hub's link reaches page 3 before the chain does.
+	var visited []string
+	numbers := make(map[string]int)
+	err := c.Crawl(context.Background(), func(p Page) {
+		path := p.URL[len(srv.URL):]
+		visited = append(visited, path)
+		numbers[path] = p.PageNumber
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"/hub", "/list", "/list?page=3", "/list?page=2", "/list?page=4"}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("Expected %v, got %v", expected, visited)
+	}
+	if numbers["/list?page=2"] != 2 || numbers["/list?page=4"] != 4 {
+		t.Errorf("Expected chain page numbers to count the skipped page, got %v", numbers)
+	}
+}
@@ -2,32 +2,211 @@
 
 package crawler
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+)
+
+/*
+Default values used when the configuration leaves a field unset.
+
+  - defaultTimeout: Upper bound for a single HTTP request, including reading the body.
+  - defaultBaseDelay: Backoff delay before the first retry; doubled on each further attempt.
+  - defaultMaxDelay: Cap applied to any single backoff delay.
+  - maxBodySize: Largest response body read, in bytes; a larger one fails with
+    ErrBodyTooLarge instead of being held in memory.
+*/
+const (
+	defaultTimeout   = 30 * time.Second
+	defaultBaseDelay = 500 * time.Millisecond
+	defaultMaxDelay  = 30 * time.Second
+	maxBodySize      = 50 << 20
+)
+
+// ErrBodyTooLarge is reported for responses whose body exceeds maxBodySize.
+var ErrBodyTooLarge = errors.New("response body too large")
+
 /*
 Crawler is responsible for fetching HTML content from URLs.
 
+Fields:
+  - client: The HTTP client used for all requests.
+  - userAgent: Value sent in the User-Agent header of every request.
+  - retryAttempts: Number of retries after the first failed attempt on transient errors.
+  - baseDelay: Initial backoff delay between retries.
+  - maxDelay: Maximum backoff delay between retries.
+  - base: The configured URL.Base that seeds and scopes a crawl.
+  - routes: The literal (non-wildcard) URL.Routes, resolved against base.
+  - patterns: Compiled wildcard URL.Routes that select which discovered links are followed.
+  - includeBase: Whether the base URL itself is a crawl seed.
+  - maxDepth: How many links deep Crawl follows from a seed.
+  - limiter: Per-host rate limiter applied before every request attempt.
+  - concurrency: Number of workers Crawl uses to fetch pages in parallel.
+  - perHostConcurrency: Maximum simultaneous requests Crawl sends to one host.
+  - hostSlots: Per-host semaphores enforcing perHostConcurrency, guarded by slotsMu.
+  - ignoreRobots: Skips robots.txt enforcement during Crawl.
+  - robots: Per-origin cache of parsed robots.txt rules.
+  - sitemapsEnabled: Whether Crawl also seeds its frontier from sitemaps.
+  - sitemapURLs: Explicit sitemap locations from URL.Sitemaps.URLs.
+  - sitemapLastmodAfter: Raw URL.Sitemaps.LastmodAfter cutoff.
+  - pagination: Compiled ScrapingOptions.Pagination; nil when pagination is off.
+
 Usage:
 
 	Create an instance of Crawler using New() and then call FetchURL
-	to retrieve the HTML content from a specified URL.
-
-Notes:
-  - This implementation is currently a stub.
-  - Future enhancements may include handling HTTP GET requests,
-    concurrency, rate-limiting, timeouts, retries, and robust error handling.
+	to retrieve the HTML content from a specified URL, or Crawl to walk
+	the configured site.
 */
 type Crawler struct {
-	// Fields for storing configuration or concurrency settings can be added here.
+	client        *http.Client
+	userAgent     string
+	retryAttempts int
+	baseDelay     time.Duration
+	maxDelay      time.Duration
+	base          string
+	routes        []string
+	patterns      []routePattern
+	includeBase   bool
+	maxDepth      int
+	limiter       *RateLimiter
+
+	concurrency        int
+	perHostConcurrency int
+	slotsMu            sync.Mutex
+	hostSlots          map[string]chan struct{}
+
+	ignoreRobots bool
+	robots       robotsCache
+
+	sitemapsEnabled     bool
+	sitemapURLs         []string
+	sitemapLastmodAfter string
+
+	pagination *pagination
 }
 
 /*
-New returns a new instance of Crawler.
+New returns a new instance of Crawler configured from cfg.
+
+Parameters:
+  - cfg: The loaded configuration. ScrapingOptions.UserAgent and
+    ScrapingOptions.RetryAttempts are used for every request; the URL
+    section (including URL.Sitemaps) and ScrapingOptions.MaxDepth drive Crawl. ScrapingOptions.RateLimit
+    (seconds between requests) and ScrapingOptions.Burst configure the per-host
+    rate limiter, and ScrapingOptions.Concurrency and PerHostConcurrency size
+    the worker pool. ScrapingOptions.IgnoreRobots disables robots.txt checks.
+    ScrapingOptions.Pagination (with ParseRules.Items to detect empty pages)
+    controls how Crawl follows paginated listings.
 
 Usage:
 
-	c := New()
+	cfg, _ := config.Load("configs/default.json")
+	c := New(cfg)
+*/
+func New(cfg *config.Config) *Crawler {
+	retries := cfg.ScrapingOptions.RetryAttempts
+	if retries < 0 {
+		retries = 0
+	}
+	concurrency := cfg.ScrapingOptions.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	perHost := cfg.ScrapingOptions.PerHostConcurrency
+	if perHost < 1 {
+		perHost = 1
+	}
+	var routes []string
+	var patterns []routePattern
+	for _, route := range cfg.URL.Routes {
+		if IsRoutePattern(route) {
+			patterns = append(patterns, compileRoutePattern(route))
+		} else {
+			routes = append(routes, route)
+		}
+	}
+
+	return &Crawler{
+		client:        &http.Client{Timeout: defaultTimeout},
+		userAgent:     cfg.ScrapingOptions.UserAgent,
+		retryAttempts: retries,
+		baseDelay:     defaultBaseDelay,
+		maxDelay:      defaultMaxDelay,
+		base:          cfg.URL.Base,
+		routes:        routes,
+		patterns:      patterns,
+		includeBase:   cfg.URL.IncludeBase,
+		maxDepth:      cfg.ScrapingOptions.MaxDepth,
+		limiter: NewRateLimiter(
+			time.Duration(cfg.ScrapingOptions.RateLimit*float64(time.Second)),
+			cfg.ScrapingOptions.Burst,
+		),
+		concurrency:         concurrency,
+		perHostConcurrency:  perHost,
+		hostSlots:           make(map[string]chan struct{}),
+		ignoreRobots:        cfg.ScrapingOptions.IgnoreRobots,
+		robots:              robotsCache{entries: make(map[string]*robotsEntry)},
+		sitemapsEnabled:     cfg.URL.Sitemaps.Enabled,
+		sitemapURLs:         cfg.URL.Sitemaps.URLs,
+		sitemapLastmodAfter: cfg.URL.Sitemaps.LastmodAfter,
+		pagination:          newPagination(cfg),
+	}
+}
+
+/*
+FetchError describes a failed fetch.
+
+Fields:
+  - URL: The URL that was requested.
+  - StatusCode: The HTTP status code of the last response, or 0 if no response was received.
+  - Err: The underlying network error, if any.
+
+Notes:
+  - Use Permanent() to decide whether retrying the request could ever succeed.
+*/
+type FetchError struct {
+	URL        string
+	StatusCode int
+	Err        error
+
+	// retryAfter holds the server-requested delay from a Retry-After header, if any.
+	retryAfter time.Duration
+}
+
+// Error implements the error interface.
+func (e *FetchError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("fetch %s: %v", e.URL, e.Err)
+	}
+	return fmt.Sprintf("fetch %s: unexpected status %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Unwrap returns the underlying network error so errors.Is and errors.As work.
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+/*
+Permanent reports whether the failure is not worth retrying.
+
+4xx responses (except 429 Too Many Requests) and bodies over maxBodySize are
+permanent; 5xx responses, 429 and network errors are transient.
 */
-func New() *Crawler {
-	return &Crawler{}
+func (e *FetchError) Permanent() bool {
+	if errors.Is(e.Err, ErrBodyTooLarge) {
+		return true
+	}
+	return e.StatusCode >= 400 && e.StatusCode < 500 && e.StatusCode != http.StatusTooManyRequests
 }
 
 /*
@@ -37,8 +216,8 @@ Parameters:
   - url: A string representing the URL to fetch.
 
 Returns:
-  - A string containing the HTML content (if successful) or an empty string.
-  - An error if the fetch operation fails.
+  - A string containing the response body (if successful) or an empty string.
+  - A *FetchError if the fetch operation fails.
 
 Usage:
 
@@ -48,10 +227,147 @@ Usage:
 	}
 
 Notes:
-  - This function is currently a stub and returns an empty string with a nil error.
-  - Future implementations will include actual HTTP request handling.
+  - This is FetchURLContext with context.Background().
 */
 func (c *Crawler) FetchURL(url string) (string, error) {
-	// Stub: return placeholder HTML or empty string for now.
-	return "", nil
+	return c.FetchURLContext(context.Background(), url)
+}
+
+/*
+FetchURLContext retrieves the HTML content from the specified URL, aborting when ctx is done.
+
+Parameters:
+  - ctx: Cancels rate-limit waits, backoff sleeps and the in-flight request.
+  - url: A string representing the URL to fetch.
+
+Returns:
+  - A string containing the response body (if successful) or an empty string.
+  - A *FetchError if the fetch operation fails; it wraps ctx.Err() on cancellation.
+
+Notes:
+  - Every attempt, including retries, first waits on the per-host rate limiter.
+  - Transient failures (network errors, 5xx and 429 responses) are retried up to
+    ScrapingOptions.RetryAttempts times with exponential backoff and jitter.
+  - A Retry-After header on a 429 or 503 response is honored when it is longer than the backoff.
+  - Permanent failures (other 4xx responses) are returned immediately.
+*/
+func (c *Crawler) FetchURLContext(ctx context.Context, url string) (string, error) {
+	var lastErr *FetchError
+	for attempt := 0; attempt <= c.retryAttempts; attempt++ {
+		if attempt > 0 {
+			if err := sleep(ctx, c.backoff(attempt, lastErr)); err != nil {
+				return "", &FetchError{URL: url, Err: err}
+			}
+		}
+
+		if err := c.limiter.Wait(ctx, hostOf(url)); err != nil {
+			return "", &FetchError{URL: url, Err: err}
+		}
+
+		body, err := c.fetchOnce(ctx, url)
+		if err == nil {
+			return body, nil
+		}
+		lastErr = err
+		if err.Permanent() || ctx.Err() != nil {
+			break
+		}
+	}
+	return "", lastErr
+}
+
+// fetchOnce performs a single GET request and classifies the outcome.
+func (c *Crawler) fetchOnce(ctx context.Context, url string) (string, *FetchError) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		// A malformed URL will never succeed, so report it as a client error.
+		return "", &FetchError{URL: url, StatusCode: http.StatusBadRequest, Err: err}
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", &FetchError{URL: url, Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		io.Copy(io.Discard, resp.Body)
+		return "", &FetchError{
+			URL:        url,
+			StatusCode: resp.StatusCode,
+			retryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize+1))
+	if err != nil {
+		return "", &FetchError{URL: url, StatusCode: resp.StatusCode, Err: err}
+	}
+	if len(body) > maxBodySize {
+		return "", &FetchError{URL: url, StatusCode: resp.StatusCode, Err: ErrBodyTooLarge}
+	}
+	return string(body), nil
+}
+
+/*
+backoff returns how long to wait before the given retry attempt (starting at 1).
+
+The delay doubles with each attempt, is capped at maxDelay, and up to half of it
+is randomized so concurrent clients do not retry in lockstep.
+*/
+func (c *Crawler) backoff(attempt int, lastErr *FetchError) time.Duration {
+	delay := c.baseDelay << (attempt - 1)
+	if delay > c.maxDelay || delay <= 0 {
+		delay = c.maxDelay
+	}
+	if half := int64(delay / 2); half > 0 {
+		delay = delay/2 + time.Duration(rand.Int63n(half+1))
+	}
+	if lastErr != nil && lastErr.retryAfter > delay {
+		delay = lastErr.retryAfter
+		if delay > c.maxDelay {
+			delay = c.maxDelay
+		}
+	}
+	return delay
+}
+
+// sleep waits for d or until ctx is done, whichever comes first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// hostOf returns the host portion of rawURL, or rawURL itself if it cannot be parsed.
+func hostOf(rawURL string) string {
+	u, err := neturl.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return u.Host
+}
+
+// parseRetryAfter converts a Retry-After header (seconds or HTTP date) into a duration.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
 }
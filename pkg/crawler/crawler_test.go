@@ -2,25 +2,261 @@
 
 package crawler
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
 
-// TestNew verifies that New returns a valid (non-nil) instance of Crawler.
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+)
+
+// newTestCrawler builds a Crawler from a defaulted config with near-zero backoff
+// and no rate limiting so retry tests run quickly.
+func newTestCrawler(retries int) *Crawler {
+	cfg := &config.Config{}
+	cfg.ApplyDefaults()
+	cfg.ScrapingOptions.RetryAttempts = retries
+	cfg.ScrapingOptions.UserAgent = "scrapey-test"
+	cfg.ScrapingOptions.RateLimit = 0
+	c := New(cfg)
+	c.baseDelay = time.Millisecond
+	c.maxDelay = 5 * time.Millisecond
+	return c
+}
+
+// TestNew verifies that New returns a Crawler configured from the given config.
 func TestNew(t *testing.T) {
-	c := New()
+	cfg := &config.Config{}
+	cfg.ApplyDefaults()
+	c := New(cfg)
 	if c == nil {
-		t.Error("Expected New() to return a non-nil Crawler instance")
+		t.Fatal("Expected New() to return a non-nil Crawler instance")
+	}
+	if c.userAgent != cfg.ScrapingOptions.UserAgent {
+		t.Errorf("Expected userAgent %q, got %q", cfg.ScrapingOptions.UserAgent, c.userAgent)
+	}
+	if c.retryAttempts != cfg.ScrapingOptions.RetryAttempts {
+		t.Errorf("Expected retryAttempts %d, got %d", cfg.ScrapingOptions.RetryAttempts, c.retryAttempts)
+	}
+
+	cfg.ScrapingOptions.RetryAttempts = -1
+	if c := New(cfg); c.retryAttempts != 0 {
+		t.Errorf("Expected negative RetryAttempts to clamp to 0, got %d", c.retryAttempts)
 	}
 }
 
-// TestFetchURL verifies that FetchURL returns an empty string and nil error
-// regardless of the input URL, as it is currently a stub.
+// TestFetchURL exercises success, retry and permanent-failure paths of FetchURL
+// against a local test server.
 func TestFetchURL(t *testing.T) {
-	c := New()
-	content, err := c.FetchURL("http://example.com")
-	if err != nil {
-		t.Errorf("Expected no error from FetchURL, got: %v", err)
+	cases := []struct {
+		desc          string
+		statuses      []int // status per request; the last one repeats
+		retries       int
+		expectBody    string
+		expectErr     bool
+		expectPerm    bool
+		expectStatus  int
+		expectAttempt int32
+	}{
+		{
+			desc:          "Success on first attempt",
+			statuses:      []int{http.StatusOK},
+			retries:       3,
+			expectBody:    "<html>ok</html>",
+			expectAttempt: 1,
+		},
+		{
+			desc:          "Transient 5xx then success",
+			statuses:      []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK},
+			retries:       3,
+			expectBody:    "<html>ok</html>",
+			expectAttempt: 3,
+		},
+		{
+			desc:          "429 is retried",
+			statuses:      []int{http.StatusTooManyRequests, http.StatusOK},
+			retries:       1,
+			expectBody:    "<html>ok</html>",
+			expectAttempt: 2,
+		},
+		{
+			desc:          "Retries exhausted",
+			statuses:      []int{http.StatusServiceUnavailable},
+			retries:       2,
+			expectErr:     true,
+			expectStatus:  http.StatusServiceUnavailable,
+			expectAttempt: 3,
+		},
+		{
+			desc:          "Permanent 404 is not retried",
+			statuses:      []int{http.StatusNotFound},
+			retries:       3,
+			expectErr:     true,
+			expectPerm:    true,
+			expectStatus:  http.StatusNotFound,
+			expectAttempt: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var attempts int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&attempts, 1)
+				if ua := r.Header.Get("User-Agent"); ua != "scrapey-test" {
+					t.Errorf("Expected User-Agent 'scrapey-test', got %q", ua)
+				}
+				idx := int(n) - 1
+				if idx >= len(tc.statuses) {
+					idx = len(tc.statuses) - 1
+				}
+				w.WriteHeader(tc.statuses[idx])
+				if tc.statuses[idx] == http.StatusOK {
+					w.Write([]byte("<html>ok</html>"))
+				}
+			}))
+			defer srv.Close()
+
+			c := newTestCrawler(tc.retries)
+			body, err := c.FetchURL(srv.URL)
+
+			if got := atomic.LoadInt32(&attempts); got != tc.expectAttempt {
+				t.Errorf("Expected %d attempts, got %d", tc.expectAttempt, got)
+			}
+			if !tc.expectErr {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if body != tc.expectBody {
+					t.Errorf("Expected body %q, got %q", tc.expectBody, body)
+				}
+				return
+			}
+
+			var fe *FetchError
+			if !errors.As(err, &fe) {
+				t.Fatalf("Expected *FetchError, got %T (%v)", err, err)
+			}
+			if fe.StatusCode != tc.expectStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectStatus, fe.StatusCode)
+			}
+			if fe.Permanent() != tc.expectPerm {
+				t.Errorf("Expected Permanent() to be %v", tc.expectPerm)
+			}
+			if body != "" {
+				t.Errorf("Expected empty body on error, got %q", body)
+			}
+		})
+	}
+}
+
+// TestFetchURLNetworkError verifies that connection failures are reported as transient.
+func TestFetchURLNetworkError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	c := newTestCrawler(1)
+	_, err := c.FetchURL(url)
+	var fe *FetchError
+	if !errors.As(err, &fe) {
+		t.Fatalf("Expected *FetchError, got %T (%v)", err, err)
+	}
+	if fe.Err == nil || fe.Permanent() {
+		t.Errorf("Expected a transient error wrapping the network failure, got %+v", fe)
+	}
+	if fe.Error() == "" {
+		t.Error("Expected non-empty error message")
+	}
+
+	_, err = c.FetchURL("://bad-url")
+	if !errors.As(err, &fe) || !fe.Permanent() {
+		t.Errorf("Expected malformed URL to be a permanent error, got %v", err)
+	}
+}
+
+// TestFetchURLBodyTooLarge verifies that a body over maxBodySize fails permanently
+// without being retried.
+func TestFetchURLBodyTooLarge(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Write(make([]byte, maxBodySize+1))
+	}))
+	defer srv.Close()
+
+	c := newTestCrawler(2)
+	_, err := c.FetchURL(srv.URL)
+	var fe *FetchError
+	if !errors.As(err, &fe) || !errors.Is(err, ErrBodyTooLarge) || !fe.Permanent() {
+		t.Fatalf("Expected a permanent ErrBodyTooLarge, got %v", err)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Errorf("Expected 1 attempt, got %d", got)
+	}
+}
+
+// TestBackoff verifies exponential growth, the maxDelay cap and Retry-After handling.
+func TestBackoff(t *testing.T) {
+	c := &Crawler{baseDelay: 100 * time.Millisecond, maxDelay: time.Second}
+
+	for attempt, max := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 400 * time.Millisecond, 10: time.Second} {
+		d := c.backoff(attempt, nil)
+		if d < max/2 || d > max {
+			t.Errorf("Attempt %d: expected delay in [%v, %v], got %v", attempt, max/2, max, d)
+		}
+	}
+
+	if d := c.backoff(1, &FetchError{retryAfter: 700 * time.Millisecond}); d != 700*time.Millisecond {
+		t.Errorf("Expected Retry-After delay of 700ms, got %v", d)
+	}
+	if d := c.backoff(1, &FetchError{retryAfter: time.Hour}); d != time.Second {
+		t.Errorf("Expected Retry-After delay capped at 1s, got %v", d)
+	}
+}
+
+// TestParseRetryAfter covers the seconds, HTTP-date and invalid forms of the header.
+func TestParseRetryAfter(t *testing.T) {
+	if d := parseRetryAfter(""); d != 0 {
+		t.Errorf("Expected 0 for empty header, got %v", d)
+	}
+	if d := parseRetryAfter("3"); d != 3*time.Second {
+		t.Errorf("Expected 3s, got %v", d)
+	}
+	if d := parseRetryAfter("garbage"); d != 0 {
+		t.Errorf("Expected 0 for invalid header, got %v", d)
+	}
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if d := parseRetryAfter(future); d <= 0 || d > time.Minute {
+		t.Errorf("Expected positive delay up to 1m for HTTP date, got %v", d)
+	}
+}
+
+// TestFetchURLContextCancelled verifies that a cancelled context aborts retries
+// and is surfaced through the returned FetchError.
+func TestFetchURLContextCancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := newTestCrawler(5)
+	c.baseDelay = time.Hour
+	c.maxDelay = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := c.FetchURLContext(ctx, srv.URL)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected error wrapping context.Canceled, got %v", err)
 	}
-	if content != "" {
-		t.Errorf("Expected empty content from FetchURL, got: %q", content)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected cancellation to interrupt backoff, took %v", elapsed)
 	}
 }
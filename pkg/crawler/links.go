@@ -0,0 +1,109 @@
+// File: pkg/crawler/links.go
+
+package crawler
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+/*
+ExtractLinks returns the absolute http(s) URLs referenced by <a href> elements in htmlContent.
+
+Parameters:
+  - base: The URL of the page the HTML was fetched from; relative links are resolved against it.
+  - htmlContent: The raw HTML of the page.
+
+Returns:
+  - A slice of normalized absolute URLs, in document order and without duplicates.
+
+Usage:
+
+	links := ExtractLinks(pageURL, body)
+
+Notes:
+  - A <base href> element in the document overrides the page URL for resolution.
+  - Fragment-only, javascript:, mailto: and other non-http(s) links are ignored.
+*/
+func ExtractLinks(base *url.URL, htmlContent string) []string {
+	var links []string
+	seen := make(map[string]bool)
+
+	tokenizer := html.NewTokenizer(strings.NewReader(htmlContent))
+	for {
+		tt := tokenizer.Next()
+		if tt == html.ErrorToken {
+			return links
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+
+		token := tokenizer.Token()
+		if token.Data != "a" && token.Data != "base" {
+			continue
+		}
+		href, ok := attr(token, "href")
+		if !ok {
+			continue
+		}
+
+		ref, err := url.Parse(strings.TrimSpace(href))
+		if err != nil {
+			continue
+		}
+		abs := base.ResolveReference(ref)
+
+		if token.Data == "base" {
+			base = abs
+			continue
+		}
+		if abs.Scheme != "http" && abs.Scheme != "https" {
+			continue
+		}
+
+		normalized := NormalizeURL(abs)
+		if !seen[normalized] {
+			seen[normalized] = true
+			links = append(links, normalized)
+		}
+	}
+}
+
+// attr returns the value of the named attribute on token.
+func attr(token html.Token, name string) (string, bool) {
+	for _, a := range token.Attr {
+		if a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+/*
+NormalizeURL returns a canonical string form of u used as the crawler's visited-set key.
+
+Notes:
+  - The scheme and host are lowercased and default ports (:80, :443) are removed.
+  - The fragment is dropped, an empty path becomes "/", and query parameters are sorted.
+*/
+func NormalizeURL(u *url.URL) string {
+	n := *u
+	n.Scheme = strings.ToLower(n.Scheme)
+	n.Host = strings.ToLower(n.Host)
+	if (n.Scheme == "http" && strings.HasSuffix(n.Host, ":80")) ||
+		(n.Scheme == "https" && strings.HasSuffix(n.Host, ":443")) {
+		n.Host = n.Host[:strings.LastIndex(n.Host, ":")]
+	}
+	n.Fragment = ""
+	n.RawFragment = ""
+	if n.Path == "" {
+		n.Path = "/"
+	}
+	if n.RawQuery != "" {
+		n.RawQuery = n.Query().Encode()
+	}
+	return n.String()
+}
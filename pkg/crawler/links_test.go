@@ -0,0 +1,82 @@
+// File: pkg/crawler/links_test.go
+
+package crawler
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// TestExtractLinks verifies resolution, filtering and de-duplication of <a href> links.
+func TestExtractLinks(t *testing.T) {
+	base, _ := url.Parse("https://example.com/blog/post")
+
+	cases := []struct {
+		desc     string
+		html     string
+		expected []string
+	}{
+		{
+			desc:     "No links",
+			html:     "<html><body><p>Hello</p></body></html>",
+			expected: nil,
+		},
+		{
+			desc: "Relative, absolute and root-relative links",
+			html: `<a href="next">n</a><a href="/about">a</a><a href="https://other.org/x">o</a>`,
+			expected: []string{
+				"https://example.com/blog/next",
+				"https://example.com/about",
+				"https://other.org/x",
+			},
+		},
+		{
+			desc:     "Non-http schemes and anchors without href are ignored",
+			html:     `<a href="mailto:me@example.com">m</a><a href="javascript:void(0)">j</a><a name="top">t</a><a href="/ok">ok</a>`,
+			expected: []string{"https://example.com/ok"},
+		},
+		{
+			desc:     "Duplicates after normalization are removed",
+			html:     `<a href="/a#one">1</a><a href="/a#two">2</a><a href="HTTPS://EXAMPLE.COM:443/a">3</a>`,
+			expected: []string{"https://example.com/a"},
+		},
+		{
+			desc:     "Base element changes resolution",
+			html:     `<head><base href="https://cdn.example.com/root/"></head><a href="page">p</a>`,
+			expected: []string{"https://cdn.example.com/root/page"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := ExtractLinks(base, tc.html)
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestNormalizeURL verifies the canonical form used for the visited set.
+func TestNormalizeURL(t *testing.T) {
+	cases := map[string]string{
+		"HTTP://Example.COM":              "http://example.com/",
+		"http://example.com:80/path":      "http://example.com/path",
+		"https://example.com:443/path#x":  "https://example.com/path",
+		"https://example.com:8443/path":   "https://example.com:8443/path",
+		"https://example.com/p?b=2&a=1":   "https://example.com/p?a=1&b=2",
+		"https://example.com/p?a=1#frag":  "https://example.com/p?a=1",
+		"https://example.com/Case/Kept/":  "https://example.com/Case/Kept/",
+		"https://example.com/space%20ok/": "https://example.com/space%20ok/",
+	}
+	for in, expected := range cases {
+		u, err := url.Parse(in)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", in, err)
+		}
+		if got := NormalizeURL(u); got != expected {
+			t.Errorf("NormalizeURL(%q): expected %q, got %q", in, expected, got)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// File: pkg/crawler/pagination.go
+
+package crawler
+
+import (
+	"crypto/sha256"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+	"golang.org/x/net/html"
+)
+
+/*
+pagination holds the compiled ScrapingOptions.Pagination settings.
+
+Fields:
+  - next: Matches the "next page" link; nil when only a URL template is configured.
+  - template: URL of page n with "{page}" in place of n, resolved against the chain's seed.
+  - maxPages: Most pages fetched per chain, counting the seed; 0 for no limit.
+  - items: Reports whether a page lists anything (ParseRules.Items matches); nil when
+    no items rule is configured, in which case a page without text counts as empty.
+*/
+type pagination struct {
+	next     cascadia.Selector
+	template string
+	maxPages int
+	items    func(*html.Node) bool
+}
+
+/*
+pageChain tracks one seed's run of paginated pages.
+
+Fields:
+  - seed: The seed URL the chain started from.
+  - page: The number of the page awaiting fetch (the seed is page 1).
+  - seen: Every URL the chain has queued, to stop on loops.
+  - digest: Hash of the previous page's content, to stop when a site keeps serving the same page.
+*/
+type pageChain struct {
+	seed   *url.URL
+	page   int
+	seen   map[string]bool
+	digest [sha256.Size]byte
+}
+
+// newPagination compiles cfg's pagination settings, returning nil when pagination is off.
+func newPagination(cfg *config.Config) *pagination {
+	opts := cfg.ScrapingOptions.Pagination
+	if opts.NextSelector == "" && opts.URLTemplate == "" {
+		return nil
+	}
+	p := &pagination{template: opts.URLTemplate, maxPages: opts.MaxPages}
+	if opts.NextSelector != "" {
+		// Load validates the selector; an invalid one disables link following.
+		if sel, err := cascadia.Compile(opts.NextSelector); err == nil {
+			p.next = sel
+		}
+	}
+	if rule := cfg.ParseRules.Items; rule != nil && rule.Selector != "" {
+		if rule.Type == config.SelectorXPath {
+			p.items = func(doc *html.Node) bool {
+				n, err := htmlquery.Query(doc, rule.Selector)
+				return err == nil && n != nil
+			}
+		} else if sel, err := cascadia.Compile(rule.Selector); err == nil {
+			p.items = func(doc *html.Node) bool { return cascadia.Query(doc, sel) != nil }
+		}
+	}
+	return p
+}
+
+// newChain starts a chain at seed, or returns nil if seed is not a valid URL.
+func newChain(seed string) *pageChain {
+	u, err := url.Parse(seed)
+	if err != nil {
+		return nil
+	}
+	return &pageChain{seed: u, page: 1, seen: map[string]bool{seed: true}}
+}
+
+/*
+pageFacts is what a chain needs to know about a fetched page. It is kept for every page
+fetched while pagination is on, so a chain can continue through a page that an ordinary
+link reached first.
+
+Fields:
+  - url: The page's URL.
+  - failed: Whether the fetch failed or the content could not be parsed.
+  - empty: Whether the page lists nothing (see empty).
+  - digest: Hash of the page's content.
+  - next: The href of the page's "next" link; "" without one or without nextSelector.
+*/
+type pageFacts struct {
+	url    string
+	failed bool
+	empty  bool
+	digest [sha256.Size]byte
+	next   string
+}
+
+// inspect records the facts advance needs about page.
+func (p *pagination) inspect(page Page) *pageFacts {
+	f := &pageFacts{url: page.URL, failed: page.Err != nil}
+	if f.failed {
+		return f
+	}
+	doc, err := html.Parse(strings.NewReader(page.Content))
+	if err != nil {
+		f.failed = true
+		return f
+	}
+	f.empty = p.empty(doc)
+	f.digest = sha256.Sum256([]byte(page.Content))
+	if p.next != nil {
+		if n := cascadia.Query(doc, p.next); n != nil {
+			f.next = nodeAttr(n, "href")
+		}
+	}
+	return f
+}
+
+/*
+advance decides where a chain continues after one of its pages was fetched.
+
+Parameters:
+  - ch: The chain the page belongs to; on success its page number and history are updated.
+  - page: The facts inspect recorded about the fetched page.
+  - base: The crawl's base URL; the chain never leaves its host.
+
+Returns:
+  - The normalized URL of the next page, and false when the chain ends: on a fetch error,
+    after maxPages pages, on an empty page, when the content repeats the previous page,
+    or when the next URL is missing, off-site or was already part of the chain.
+*/
+func (p *pagination) advance(ch *pageChain, page *pageFacts, base *url.URL) (string, bool) {
+	if page.failed || page.empty || (p.maxPages > 0 && ch.page >= p.maxPages) {
+		return "", false
+	}
+	if ch.page > 1 && page.digest == ch.digest {
+		return "", false
+	}
+	ch.digest = page.digest
+
+	var ref string
+	var against *url.URL
+	if p.next != nil {
+		u, err := url.Parse(page.url)
+		if err != nil {
+			return "", false
+		}
+		against, ref = u, page.next
+	} else {
+		against = ch.seed
+		ref = strings.ReplaceAll(p.template, "{page}", strconv.Itoa(ch.page+1))
+	}
+	if ref == "" {
+		return "", false
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", false
+	}
+	abs := against.ResolveReference(u)
+	if abs.Scheme != "http" && abs.Scheme != "https" {
+		return "", false
+	}
+	next := NormalizeURL(abs)
+	if ch.seen[next] || !sameHost(base, next) {
+		return "", false
+	}
+	ch.seen[next] = true
+	ch.page++
+	return next, true
+}
+
+// empty reports whether a page lists nothing: the items rule matches nothing or, without
+// one, the body has no visible text.
+func (p *pagination) empty(doc *html.Node) bool {
+	if p.items != nil {
+		return !p.items(doc)
+	}
+	return !hasText(doc)
+}
+
+// hasText reports whether n contains non-whitespace text outside scripts and styles.
+func hasText(n *html.Node) bool {
+	if n.Type == html.TextNode {
+		return strings.TrimSpace(n.Data) != ""
+	}
+	if n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style" || n.Data == "head" || n.Data == "noscript" || n.Data == "template") {
+		return false
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if hasText(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeAttr returns the trimmed value of n's attribute key, or "".
+func nodeAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return strings.TrimSpace(a.Val)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,161 @@
+// File: pkg/crawler/ratelimit.go
+
+package crawler
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+RateLimiter is a per-host token bucket limiter that is safe for concurrent use.
+
+Each host gets its own bucket holding up to burst tokens. One token is consumed per
+request and tokens refill at a rate of one per interval, so a host sees at most burst
+requests back-to-back and one request per interval on average after that.
+
+Usage:
+
+	limiter := NewRateLimiter(1500*time.Millisecond, 2)
+	if err := limiter.Wait(ctx, "example.com"); err != nil {
+	    // Context was cancelled while waiting.
+	}
+
+Notes:
+  - A zero or negative interval disables limiting for hosts without a SetMinInterval override.
+  - A single RateLimiter can be shared by any number of goroutines or crawlers.
+*/
+type RateLimiter struct {
+	mu        sync.Mutex
+	interval  time.Duration
+	burst     int
+	buckets   map[string]*bucket
+	intervals map[string]time.Duration
+	now       func() time.Time
+}
+
+// bucket tracks the available tokens for one host as of the last update.
+// tokens may go negative while callers hold reservations for future slots.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+/*
+NewRateLimiter returns a RateLimiter that allows one request per interval per host,
+with bursts of up to burst requests.
+
+Parameters:
+  - interval: Minimum average delay between requests to the same host.
+  - burst: Number of requests allowed back-to-back; values below 1 are treated as 1.
+*/
+func NewRateLimiter(interval time.Duration, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		interval:  interval,
+		burst:     burst,
+		buckets:   make(map[string]*bucket),
+		intervals: make(map[string]time.Duration),
+		now:       time.Now,
+	}
+}
+
+/*
+Wait blocks until a request to host is allowed or ctx is done.
+
+Parameters:
+  - ctx: Cancels the wait; the reserved slot is released if this happens.
+  - host: The host the request is for; matching is case-insensitive.
+
+Returns:
+  - nil when the request may proceed, or ctx.Err() if the context ended first.
+*/
+func (l *RateLimiter) Wait(ctx context.Context, host string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	delay := l.reserve(host)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.release(host)
+		return ctx.Err()
+	}
+}
+
+/*
+SetMinInterval raises the interval for a single host, e.g. to honor a robots.txt Crawl-delay.
+
+Parameters:
+  - host: The host to slow down; matching is case-insensitive.
+  - interval: The minimum average delay between requests to host. Values at or below
+    the limiter's default interval have no effect.
+
+Notes:
+  - The host's burst drops to 1, so every request to it is spaced by interval rather
+    than the first burst going out back-to-back.
+*/
+func (l *RateLimiter) SetMinInterval(host string, interval time.Duration) {
+	host = strings.ToLower(host)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if interval > l.interval && interval > l.intervals[host] {
+		l.intervals[host] = interval
+	}
+}
+
+// reserve takes a token from the host's bucket and returns how long the caller
+// must wait before the token becomes valid.
+func (l *RateLimiter) reserve(host string) time.Duration {
+	host = strings.ToLower(host)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	interval, burst := l.interval, float64(l.burst)
+	if override, ok := l.intervals[host]; ok {
+		interval, burst = override, 1
+	}
+	if interval <= 0 {
+		return 0
+	}
+
+	now := l.now()
+	b, ok := l.buckets[host]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[host] = b
+	}
+
+	b.tokens += float64(now.Sub(b.last)) / float64(interval)
+	if b.tokens > burst {
+		b.tokens = burst
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens * float64(interval))
+}
+
+// release returns a token reserved by a cancelled Wait.
+func (l *RateLimiter) release(host string) {
+	host = strings.ToLower(host)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b, ok := l.buckets[host]; ok {
+		b.tokens++
+	}
+}
@@ -0,0 +1,169 @@
+// File: pkg/crawler/ratelimit_test.go
+
+package crawler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock returns a controllable time source for RateLimiter.now.
+func fakeClock(start time.Time) (func() time.Time, func(time.Duration)) {
+	var mu sync.Mutex
+	now := start
+	return func() time.Time {
+			mu.Lock()
+			defer mu.Unlock()
+			return now
+		}, func(d time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			now = now.Add(d)
+		}
+}
+
+// TestRateLimiterReserve verifies burst allowance, refill and per-host isolation
+// using a fake clock.
+func TestRateLimiterReserve(t *testing.T) {
+	l := NewRateLimiter(time.Second, 2)
+	now, advance := fakeClock(time.Unix(0, 0))
+	l.now = now
+
+	// Two requests fit in the burst, the third must wait a full interval.
+	if d := l.reserve("a.com"); d != 0 {
+		t.Errorf("Expected first request to proceed, got wait %v", d)
+	}
+	if d := l.reserve("a.com"); d != 0 {
+		t.Errorf("Expected second request to proceed within burst, got wait %v", d)
+	}
+	if d := l.reserve("a.com"); d != time.Second {
+		t.Errorf("Expected third request to wait 1s, got %v", d)
+	}
+	// A fourth concurrent reservation queues behind the third.
+	if d := l.reserve("a.com"); d != 2*time.Second {
+		t.Errorf("Expected fourth request to wait 2s, got %v", d)
+	}
+
+	// Other hosts have their own bucket.
+	if d := l.reserve("b.com"); d != 0 {
+		t.Errorf("Expected other host to proceed, got wait %v", d)
+	}
+
+	// After enough time passes the bucket refills, but never above burst.
+	advance(10 * time.Second)
+	for i := 0; i < 2; i++ {
+		if d := l.reserve("A.COM"); d != 0 {
+			t.Errorf("Expected refilled request %d to proceed, got wait %v", i, d)
+		}
+	}
+	if d := l.reserve("a.com"); d != time.Second {
+		t.Errorf("Expected refill to be capped at burst, got wait %v", d)
+	}
+}
+
+// TestRateLimiterSetMinInterval verifies per-host interval overrides.
+func TestRateLimiterSetMinInterval(t *testing.T) {
+	l := NewRateLimiter(time.Second, 1)
+	now, _ := fakeClock(time.Unix(0, 0))
+	l.now = now
+
+	l.SetMinInterval("Slow.com", 5*time.Second)
+	l.SetMinInterval("fast.com", 500*time.Millisecond) // below default: ignored
+	l.SetMinInterval("slow.com", 2*time.Second)        // lower than current: ignored
+
+	l.reserve("slow.com")
+	if d := l.reserve("slow.com"); d != 5*time.Second {
+		t.Errorf("Expected slow.com to wait 5s, got %v", d)
+	}
+	l.reserve("fast.com")
+	if d := l.reserve("fast.com"); d != time.Second {
+		t.Errorf("Expected fast.com to keep the 1s default, got %v", d)
+	}
+
+	// An override also applies when the default interval disables limiting.
+	off := NewRateLimiter(0, 1)
+	off.now = now
+	off.SetMinInterval("a.com", time.Second)
+	off.reserve("a.com")
+	if d := off.reserve("a.com"); d != time.Second {
+		t.Errorf("Expected override to limit a.com, got %v", d)
+	}
+	if d := off.reserve("b.com"); d != 0 {
+		t.Errorf("Expected b.com to remain unlimited, got %v", d)
+	}
+
+	// A Crawl-delay override spaces every request, even with a larger burst.
+	bursty := NewRateLimiter(time.Second, 3)
+	bursty.now = now
+	bursty.SetMinInterval("slow.com", 10*time.Second)
+	bursty.reserve("slow.com")
+	if d := bursty.reserve("slow.com"); d != 10*time.Second {
+		t.Errorf("Expected the override to cap the burst at 1, got wait %v", d)
+	}
+	bursty.reserve("other.com")
+	if d := bursty.reserve("other.com"); d != 0 {
+		t.Errorf("Expected other hosts to keep their burst, got wait %v", d)
+	}
+}
+
+// TestRateLimiterDisabled verifies that a non-positive interval never blocks.
+func TestRateLimiterDisabled(t *testing.T) {
+	l := NewRateLimiter(0, 0)
+	if l.burst != 1 {
+		t.Errorf("Expected burst to be clamped to 1, got %d", l.burst)
+	}
+	for i := 0; i < 5; i++ {
+		if err := l.Wait(context.Background(), "example.com"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+}
+
+// TestRateLimiterWait verifies real waiting and cancellation behavior.
+func TestRateLimiterWait(t *testing.T) {
+	l := NewRateLimiter(30*time.Millisecond, 1)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(ctx, "example.com"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 55*time.Millisecond {
+		t.Errorf("Expected three requests to take at least ~60ms, took %v", elapsed)
+	}
+
+	// A cancelled wait returns the context error and gives its slot back.
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := l.Wait(cancelled, "example.com"); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if tokens := l.buckets["example.com"].tokens; tokens < -1 {
+		t.Errorf("Expected cancelled reservation to be released, tokens=%v", tokens)
+	}
+}
+
+// TestRateLimiterConcurrent verifies the limiter is safe to share between goroutines.
+func TestRateLimiterConcurrent(t *testing.T) {
+	l := NewRateLimiter(5*time.Millisecond, 2)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.Wait(context.Background(), "example.com"); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	// Two requests burst immediately; the remaining four are spaced 5ms apart.
+	if elapsed := time.Since(start); elapsed < 18*time.Millisecond {
+		t.Errorf("Expected concurrent waits to be spaced out, took %v", elapsed)
+	}
+}
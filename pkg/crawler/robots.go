@@ -0,0 +1,330 @@
+// File: pkg/crawler/robots.go
+
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRobotsDisallowed is reported for pages that robots.txt forbids crawling.
+var ErrRobotsDisallowed = errors.New("disallowed by robots.txt")
+
+/*
+Robots holds the robots.txt rules that apply to one user agent on one host.
+
+Fields:
+  - CrawlDelay: The Crawl-delay requested for the user agent, or 0 if none.
+  - Sitemaps: Sitemap URLs listed in the file (these apply to every user agent).
+
+Usage:
+
+	robots := ParseRobots(body, "MyBot/1.0")
+	if robots.Allowed(pageURL) {
+	    // Fetch the page.
+	}
+*/
+type Robots struct {
+	CrawlDelay time.Duration
+	Sitemaps   []string
+
+	rules       []robotsRule
+	disallowAll bool
+}
+
+// robotsRule is a single Allow or Disallow line.
+type robotsRule struct {
+	allow   bool
+	length  int
+	pattern *regexp.Regexp
+}
+
+// robotsGroup collects the rules that follow one or more User-agent lines.
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// allowAllRobots is used when a host has no robots.txt.
+var allowAllRobots = &Robots{}
+
+// disallowAllRobots is used when a host's robots.txt is unreachable.
+var disallowAllRobots = &Robots{disallowAll: true}
+
+/*
+ParseRobots parses a robots.txt document and selects the rules for userAgent.
+
+Parameters:
+  - content: The robots.txt body.
+  - userAgent: The full User-Agent string the crawler sends.
+
+Returns:
+  - The Robots rules for the most specific matching group, falling back to the "*"
+    group, or an allow-all Robots if no group applies. A document that cannot be read
+    to the end disallows everything, as for an unavailable robots.txt.
+
+Notes:
+  - A group matches when its User-agent token equals (case-insensitively) one of the
+    product tokens of userAgent, the names before "/" outside parentheses: "ScrapeyBot"
+    in "ScrapeyBot/1.0 (+https://example.com/bot)". The longest matching token wins,
+    and the rules of all groups naming it (or of all "*" groups) are combined.
+  - Rules support the "*" wildcard and a trailing "$" end anchor.
+*/
+func ParseRobots(content, userAgent string) *Robots {
+	var groups []*robotsGroup
+	var current *robotsGroup
+	var sitemaps []string
+	lastWasAgent := false
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	// Any line that fits in a response body is read, rather than the default 64 KB.
+	scanner.Buffer(nil, maxBodySize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || !lastWasAgent {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			lastWasAgent = true
+			continue
+		case "allow", "disallow":
+			if current != nil && value != "" {
+				current.rules = append(current.rules, robotsRule{
+					allow:   key == "allow",
+					length:  len(value),
+					pattern: compileRobotsPattern(value),
+				})
+			}
+		case "crawl-delay":
+			if current != nil {
+				if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+					current.crawlDelay = time.Duration(secs * float64(time.Second))
+				}
+			}
+		case "sitemap":
+			if value != "" {
+				sitemaps = append(sitemaps, value)
+			}
+		}
+		lastWasAgent = false
+	}
+	if scanner.Err() != nil {
+		return disallowAllRobots
+	}
+
+	robots := &Robots{Sitemaps: sitemaps}
+	if g := selectRobotsGroup(groups, userAgent); g != nil {
+		robots.rules = g.rules
+		robots.CrawlDelay = g.crawlDelay
+	}
+	return robots
+}
+
+// selectRobotsGroup merges the groups whose agent token best matches userAgent: every
+// group naming the longest matching product token or, if none does, every "*" group.
+// It returns nil when no group applies.
+func selectRobotsGroup(groups []*robotsGroup, userAgent string) *robotsGroup {
+	products := productTokens(userAgent)
+	var best, wildcard []*robotsGroup
+	bestLen := 0
+	for _, g := range groups {
+		matched := 0
+		for _, agent := range g.agents {
+			if agent == "*" {
+				if len(wildcard) == 0 || wildcard[len(wildcard)-1] != g {
+					wildcard = append(wildcard, g)
+				}
+				continue
+			}
+			agent, _, _ = strings.Cut(agent, "/")
+			if agent != "" && products[agent] && len(agent) > matched {
+				matched = len(agent)
+			}
+		}
+		switch {
+		case matched > bestLen:
+			best, bestLen = []*robotsGroup{g}, matched
+		case matched > 0 && matched == bestLen:
+			best = append(best, g)
+		}
+	}
+	if len(best) == 0 {
+		best = wildcard
+	}
+	if len(best) == 0 {
+		return nil
+	}
+	// The strictest Crawl-delay of the merged groups applies.
+	merged := &robotsGroup{}
+	for _, g := range best {
+		merged.rules = append(merged.rules, g.rules...)
+		merged.crawlDelay = max(merged.crawlDelay, g.crawlDelay)
+	}
+	return merged
+}
+
+// productTokens returns the lower-cased product names of a User-Agent string, skipping
+// parenthesized comments.
+func productTokens(userAgent string) map[string]bool {
+	tokens := make(map[string]bool)
+	depth := 0
+	for _, field := range strings.Fields(strings.ToLower(userAgent)) {
+		if depth == 0 && !strings.HasPrefix(field, "(") {
+			if name, _, _ := strings.Cut(field, "/"); name != "" {
+				tokens[name] = true
+			}
+		}
+		depth += strings.Count(field, "(") - strings.Count(field, ")")
+		if depth < 0 {
+			depth = 0
+		}
+	}
+	return tokens
+}
+
+// compileRobotsPattern converts a robots.txt path pattern into an anchored regexp.
+func compileRobotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+/*
+Allowed reports whether u may be crawled under these rules.
+
+Notes:
+  - The longest matching rule wins; on a tie, Allow wins.
+  - The path and query string are matched; the scheme and host are ignored.
+*/
+func (r *Robots) Allowed(u *url.URL) bool {
+	if r.disallowAll {
+		return false
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+
+	allowed, bestLen := true, -1
+	for _, rule := range r.rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if rule.length > bestLen || (rule.length == bestLen && rule.allow) {
+			allowed, bestLen = rule.allow, rule.length
+		}
+	}
+	return allowed
+}
+
+/*
+robotsCache fetches and caches robots.txt once per origin (scheme and host).
+
+Concurrent callers asking for the same origin share a single fetch.
+*/
+type robotsCache struct {
+	mu      sync.Mutex
+	entries map[string]*robotsEntry
+}
+
+// robotsEntry holds the (eventual) rules for one origin.
+type robotsEntry struct {
+	once   sync.Once
+	robots *Robots
+}
+
+/*
+robotsFor returns the robots.txt rules governing rawURL, fetching them on first use.
+
+Notes:
+  - A 4xx response (including 404) means there are no restrictions.
+  - A 429 or 5xx response, or a network failure, means the whole origin is disallowed.
+  - The first time an origin's rules are loaded, a Crawl-delay longer than
+    ScrapingOptions.RateLimit tightens the rate limiter for that host.
+*/
+func (c *Crawler) robotsFor(ctx context.Context, rawURL string) *Robots {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return allowAllRobots
+	}
+	origin := strings.ToLower(u.Scheme + "://" + u.Host)
+
+	c.robots.mu.Lock()
+	entry, ok := c.robots.entries[origin]
+	if !ok {
+		entry = &robotsEntry{}
+		c.robots.entries[origin] = entry
+	}
+	c.robots.mu.Unlock()
+
+	entry.once.Do(func() {
+		entry.robots = c.fetchRobots(ctx, origin)
+		if entry.robots.CrawlDelay > 0 {
+			c.limiter.SetMinInterval(u.Host, entry.robots.CrawlDelay)
+		}
+	})
+	return entry.robots
+}
+
+// fetchRobots downloads and parses origin's robots.txt.
+func (c *Crawler) fetchRobots(ctx context.Context, origin string) *Robots {
+	body, err := c.FetchURLContext(ctx, origin+"/robots.txt")
+	if err == nil {
+		return ParseRobots(body, c.userAgent)
+	}
+	// A missing robots.txt allows everything; one that is unavailable or too large to
+	// read forbids everything, as the rules it holds are unknown.
+	var fe *FetchError
+	if errors.As(err, &fe) && fe.Permanent() && !errors.Is(err, ErrBodyTooLarge) {
+		return allowAllRobots
+	}
+	return disallowAllRobots
+}
+
+// robotsAllowed reports whether Crawl may fetch rawURL.
+func (c *Crawler) robotsAllowed(ctx context.Context, rawURL string) bool {
+	if c.ignoreRobots {
+		return true
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return c.robotsFor(ctx, rawURL).Allowed(u)
+}
@@ -0,0 +1,226 @@
+// File: pkg/crawler/robots_test.go
+
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+)
+
+const testRobots = `
+# Comments are ignored.
+User-agent: *
+Disallow: /private/
+Allow: /private/public-page
+Disallow: /*.pdf$
+Crawl-delay: 2
+
+User-agent: scrapeybot
+User-agent: otherbot
+Disallow: /bots-only-blocked
+Crawl-delay: 0.5
+
+Sitemap: https://example.com/sitemap.xml
+Sitemap: https://example.com/news-sitemap.xml
+`
+
+// TestParseRobots verifies group selection, rule precedence, wildcards and metadata.
+func TestParseRobots(t *testing.T) {
+	cases := []struct {
+		desc      string
+		userAgent string
+		delay     time.Duration
+		allowed   []string
+		blocked   []string
+	}{
+		{
+			desc:      "Generic agent uses the * group",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36",
+			delay:     2 * time.Second,
+			allowed:   []string{"/", "/robots.txt", "/private/public-page", "/docs/file.pdf?x=1", "/bots-only-blocked"},
+			blocked:   []string{"/private/", "/private/secret", "/docs/file.pdf"},
+		},
+		{
+			desc:      "Specific agent uses its own group only",
+			userAgent: "ScrapeyBot/1.0 (+https://example.com/bot)",
+			delay:     500 * time.Millisecond,
+			allowed:   []string{"/private/secret", "/docs/file.pdf"},
+			blocked:   []string{"/bots-only-blocked", "/bots-only-blocked/child"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := ParseRobots(testRobots, tc.userAgent)
+			if r.CrawlDelay != tc.delay {
+				t.Errorf("Expected crawl delay %v, got %v", tc.delay, r.CrawlDelay)
+			}
+			expectedSitemaps := []string{"https://example.com/sitemap.xml", "https://example.com/news-sitemap.xml"}
+			if !reflect.DeepEqual(r.Sitemaps, expectedSitemaps) {
+				t.Errorf("Expected sitemaps %v, got %v", expectedSitemaps, r.Sitemaps)
+			}
+			for _, path := range tc.allowed {
+				u, _ := url.Parse("https://example.com" + path)
+				if !r.Allowed(u) {
+					t.Errorf("Expected %s to be allowed", path)
+				}
+			}
+			for _, path := range tc.blocked {
+				u, _ := url.Parse("https://example.com" + path)
+				if r.Allowed(u) {
+					t.Errorf("Expected %s to be disallowed", path)
+				}
+			}
+		})
+	}
+}
+
+// TestParseRobotsEdgeCases covers empty files, empty Disallow, tie-breaking, repeated
+// groups and long lines.
+func TestParseRobotsEdgeCases(t *testing.T) {
+	u, _ := url.Parse("https://example.com/page")
+
+	if !ParseRobots("", "bot").Allowed(u) {
+		t.Error("Expected an empty robots.txt to allow everything")
+	}
+	if !ParseRobots("User-agent: *\nDisallow:\n", "bot").Allowed(u) {
+		t.Error("Expected an empty Disallow to allow everything")
+	}
+	if !ParseRobots("User-agent: *\nDisallow: /page\nAllow: /page\n", "bot").Allowed(u) {
+		t.Error("Expected Allow to win a tie with Disallow")
+	}
+	if ParseRobots("User-agent: other\nDisallow: /\n", "bot").CrawlDelay != 0 || !ParseRobots("User-agent: other\nDisallow: /\n", "bot").Allowed(u) {
+		t.Error("Expected no restrictions when no group matches")
+	}
+	if !ParseRobots("User-agent: bot\nDisallow: /\n", "NotABot/1.0 (compatible; bot)").Allowed(u) {
+		t.Error("Expected agents to match product tokens, not substrings or comments")
+	}
+	if ParseRobots("User-agent: ScrapeyBot/2.0\nDisallow: /\n", "Mozilla/5.0 scrapeybot/1.0").Allowed(u) {
+		t.Error("Expected a product token to match regardless of case and version")
+	}
+	split := "User-agent: *\nDisallow: /page\n\nUser-agent: other\nDisallow: /\n\nUser-agent: *\nAllow: /page/open\nDisallow: /other\n"
+	open, _ := url.Parse("https://example.com/page/open")
+	other, _ := url.Parse("https://example.com/other")
+	if r := ParseRobots(split, "bot"); r.Allowed(u) || !r.Allowed(open) || r.Allowed(other) {
+		t.Error("Expected the rules of every * group to be combined")
+	}
+	twice := "User-agent: bot\nDisallow: /page\n\nUser-agent: *\nDisallow: /\n\nUser-agent: bot\nDisallow: /other\n"
+	root, _ := url.Parse("https://example.com/")
+	if r := ParseRobots(twice, "bot/1.0"); r.Allowed(u) || r.Allowed(other) || !r.Allowed(root) {
+		t.Error("Expected the rules of every group naming the agent to be combined")
+	}
+	long := "User-agent: *\n# " + strings.Repeat("x", 100<<10) + "\nDisallow: /page\n"
+	if ParseRobots(long, "bot").Allowed(u) {
+		t.Error("Expected rules after a long line to apply")
+	}
+	if ParseRobots("User-agent: *\n"+strings.Repeat("x", maxBodySize+1), "bot").Allowed(u) {
+		t.Error("Expected an unreadable robots.txt to disallow everything")
+	}
+	if disallowAllRobots.Allowed(u) {
+		t.Error("Expected disallowAllRobots to block everything")
+	}
+}
+
+// TestCrawlRobots verifies enforcement during a crawl, caching, Crawl-delay
+// handling and the IgnoreRobots override.
+func TestCrawlRobots(t *testing.T) {
+	var robotsHits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			atomic.AddInt32(&robotsHits, 1)
+			fmt.Fprint(w, "User-agent: *\nDisallow: /secret\nCrawl-delay: 7\n")
+		case "/":
+			fmt.Fprint(w, `<a href="/open">o</a><a href="/secret">s</a>`)
+		}
+	}))
+	defer srv.Close()
+
+	run := func(ignore bool) (*Crawler, []string, []string) {
+		cfg := &config.Config{}
+		cfg.URL.Base = srv.URL
+		cfg.ScrapingOptions.MaxDepth = 1
+		cfg.ScrapingOptions.Concurrency = 1
+		cfg.ScrapingOptions.IgnoreRobots = ignore
+		cfg.ApplyDefaults()
+		cfg.ScrapingOptions.RateLimit = 0
+		c := New(cfg)
+		// Keep the test fast: the Crawl-delay would otherwise space requests 7s apart.
+		var tick int64
+		c.limiter.now = func() time.Time {
+			return time.Unix(atomic.AddInt64(&tick, 3600), 0)
+		}
+
+		var ok, blocked []string
+		err := c.Crawl(context.Background(), func(p Page) {
+			path := p.URL[len(srv.URL):]
+			if errors.Is(p.Err, ErrRobotsDisallowed) {
+				blocked = append(blocked, path)
+			} else {
+				ok = append(ok, path)
+			}
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return c, ok, blocked
+	}
+
+	c, ok, blocked := run(false)
+	if !reflect.DeepEqual(ok, []string{"/", "/open"}) || !reflect.DeepEqual(blocked, []string{"/secret"}) {
+		t.Errorf("Expected /secret to be blocked, got ok=%v blocked=%v", ok, blocked)
+	}
+	if n := atomic.LoadInt32(&robotsHits); n != 1 {
+		t.Errorf("Expected robots.txt to be fetched once, got %d", n)
+	}
+	host, _ := url.Parse(srv.URL)
+	if d := c.limiter.intervals[host.Host]; d != 7*time.Second {
+		t.Errorf("Expected Crawl-delay to set a 7s interval, got %v", d)
+	}
+
+	atomic.StoreInt32(&robotsHits, 0)
+	_, ok, blocked = run(true)
+	if len(blocked) != 0 || len(ok) != 3 {
+		t.Errorf("Expected IgnoreRobots to allow every page, got ok=%v blocked=%v", ok, blocked)
+	}
+	if n := atomic.LoadInt32(&robotsHits); n != 0 {
+		t.Errorf("Expected robots.txt not to be fetched when ignored, got %d", n)
+	}
+}
+
+// TestFetchRobotsStatus verifies how robots.txt fetch failures are interpreted.
+func TestFetchRobotsStatus(t *testing.T) {
+	cases := []struct {
+		status  int
+		allowed bool
+	}{
+		{http.StatusNotFound, true},
+		{http.StatusForbidden, true},
+		{http.StatusTooManyRequests, false},
+		{http.StatusInternalServerError, false},
+	}
+	for _, tc := range cases {
+		t.Run(http.StatusText(tc.status), func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+			}))
+			defer srv.Close()
+
+			c := newTestCrawler(0)
+			if got := c.robotsAllowed(context.Background(), srv.URL+"/page"); got != tc.allowed {
+				t.Errorf("Expected allowed=%v for status %d, got %v", tc.allowed, tc.status, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// File: pkg/crawler/routes.go
+
+package crawler
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+/*
+IsRoutePattern reports whether a URL.Routes entry is a wildcard pattern rather than a literal path.
+
+Usage:
+
+	IsRoutePattern("/about")       // false
+	IsRoutePattern("*")            // true
+	IsRoutePattern("/blog/*")      // true
+	IsRoutePattern("/products/**") // true
+*/
+func IsRoutePattern(route string) bool {
+	return strings.Contains(route, "*")
+}
+
+/*
+routePattern is a compiled wildcard route.
+
+Fields:
+  - prefix: The static path before the first wildcard segment; used as a discovery seed.
+  - re: Matches URL paths covered by the pattern.
+*/
+type routePattern struct {
+	prefix string
+	re     *regexp.Regexp
+}
+
+/*
+compileRoutePattern converts a wildcard route into a routePattern.
+
+Syntax:
+  - "*" on its own matches every path on the site.
+  - "*" inside a pattern matches any characters within a single path segment.
+  - "**" matches any characters across segments.
+
+Notes:
+  - Patterns without a leading "/" are treated as relative to the site root.
+  - Only the URL path is matched; query strings and fragments are ignored.
+*/
+func compileRoutePattern(route string) routePattern {
+	route = strings.TrimSpace(route)
+	if route == "*" {
+		route = "/**"
+	}
+	if !strings.HasPrefix(route, "/") {
+		route = "/" + route
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(route); {
+		switch {
+		case strings.HasPrefix(route[i:], "**"):
+			b.WriteString(".*")
+			i += 2
+		case route[i] == '*':
+			b.WriteString("[^/]*")
+			i++
+		default:
+			j := strings.IndexByte(route[i:], '*')
+			if j < 0 {
+				j = len(route) - i
+			}
+			b.WriteString(regexp.QuoteMeta(route[i : i+j]))
+			i += j
+		}
+	}
+	b.WriteString("$")
+
+	static := route[:strings.IndexByte(route, '*')]
+	return routePattern{
+		prefix: static[:strings.LastIndexByte(static, '/')+1],
+		re:     regexp.MustCompile(b.String()),
+	}
+}
+
+// matches reports whether link's path is covered by the pattern.
+func (p routePattern) matches(link *url.URL) bool {
+	path := link.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return p.re.MatchString(path)
+}
+
+// followable reports whether a discovered link should be enqueued. With no
+// wildcard routes configured every link is followable; otherwise the link must
+// match at least one pattern.
+func (c *Crawler) followable(link string) bool {
+	if len(c.patterns) == 0 {
+		return true
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	for _, p := range c.patterns {
+		if p.matches(u) {
+			return true
+		}
+	}
+	return false
+}
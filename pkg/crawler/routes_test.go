@@ -0,0 +1,104 @@
+// File: pkg/crawler/routes_test.go
+
+package crawler
+
+import (
+	"net/url"
+	"testing"
+)
+
+// TestIsRoutePattern verifies detection of wildcard routes.
+func TestIsRoutePattern(t *testing.T) {
+	cases := map[string]bool{
+		"/":            false,
+		"/about":       false,
+		"*":            true,
+		"/blog/*":      true,
+		"/products/**": true,
+	}
+	for route, expected := range cases {
+		if got := IsRoutePattern(route); got != expected {
+			t.Errorf("IsRoutePattern(%q): expected %v, got %v", route, expected, got)
+		}
+	}
+}
+
+// TestCompileRoutePattern verifies glob matching and static prefix extraction.
+func TestCompileRoutePattern(t *testing.T) {
+	cases := []struct {
+		pattern  string
+		prefix   string
+		matches  []string
+		excludes []string
+	}{
+		{
+			pattern: "*",
+			prefix:  "/",
+			matches: []string{"/", "/a", "/a/b/c"},
+		},
+		{
+			pattern:  "/blog/*",
+			prefix:   "/blog/",
+			matches:  []string{"/blog/", "/blog/post", "/blog/post?page=2"},
+			excludes: []string{"/blog", "/blog/2024/post", "/other/post"},
+		},
+		{
+			pattern:  "/products/**",
+			prefix:   "/products/",
+			matches:  []string{"/products/", "/products/a", "/products/a/b/c"},
+			excludes: []string{"/product", "/shop/products/a"},
+		},
+		{
+			pattern:  "docs/*.html",
+			prefix:   "/docs/",
+			matches:  []string{"/docs/index.html"},
+			excludes: []string{"/docs/indexxhtml", "/docs/a/b.html"},
+		},
+		{
+			pattern:  "/blog/post-*",
+			prefix:   "/blog/",
+			matches:  []string{"/blog/post-1", "/blog/post-"},
+			excludes: []string{"/blog/page-1"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.pattern, func(t *testing.T) {
+			p := compileRoutePattern(tc.pattern)
+			if p.prefix != tc.prefix {
+				t.Errorf("Expected prefix %q, got %q", tc.prefix, p.prefix)
+			}
+			for _, path := range tc.matches {
+				u, _ := url.Parse("https://example.com" + path)
+				if !p.matches(u) {
+					t.Errorf("Expected %q to match %q", tc.pattern, path)
+				}
+			}
+			for _, path := range tc.excludes {
+				u, _ := url.Parse("https://example.com" + path)
+				if p.matches(u) {
+					t.Errorf("Expected %q not to match %q", tc.pattern, path)
+				}
+			}
+		})
+	}
+}
+
+// TestFollowable verifies link filtering with and without wildcard routes.
+func TestFollowable(t *testing.T) {
+	c := &Crawler{}
+	if !c.followable("https://example.com/anything") {
+		t.Error("Expected every link to be followable without patterns")
+	}
+
+	c.patterns = []routePattern{compileRoutePattern("/blog/*")}
+	if !c.followable("https://example.com/blog/post") {
+		t.Error("Expected /blog/post to be followable")
+	}
+	if c.followable("https://example.com/shop") {
+		t.Error("Expected /shop not to be followable")
+	}
+	if c.followable("://bad") {
+		t.Error("Expected an unparsable link not to be followable")
+	}
+}
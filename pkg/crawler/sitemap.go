@@ -0,0 +1,240 @@
+// File: pkg/crawler/sitemap.go
+
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+)
+
+/*
+Limits applied to sitemaps.
+
+  - maxSitemapDepth: Levels of nested sitemap indexes that are followed.
+  - maxSitemapSize: Largest uncompressed sitemap accepted, in bytes: the response
+    body limit, which matches the sitemap protocol's 50 MB, so a small gzip file cannot
+    expand without bound either.
+*/
+const (
+	maxSitemapDepth = 5
+	maxSitemapSize  = maxBodySize
+)
+
+/*
+SitemapError reports a sitemap that SitemapURLs skipped.
+
+Fields:
+  - URL: The sitemap's location.
+  - Err: Why it was skipped: the fetch or parse error.
+*/
+type SitemapError struct {
+	URL string
+	Err error
+}
+
+func (e *SitemapError) Error() string {
+	return fmt.Sprintf("skipped sitemap %s: %v", e.URL, e.Err)
+}
+
+// Unwrap returns the underlying fetch or parse error.
+func (e *SitemapError) Unwrap() error {
+	return e.Err
+}
+
+/*
+SitemapEntry is a single <url> or <sitemap> element of a sitemap document.
+
+Fields:
+  - Loc: The absolute URL of the page (or nested sitemap).
+  - LastMod: The parsed <lastmod> value, or the zero time if absent or unparsable.
+*/
+type SitemapEntry struct {
+	Loc     string
+	LastMod time.Time
+}
+
+/*
+Sitemap is a parsed sitemap document.
+
+Fields:
+  - URLs: Page entries from a <urlset> document.
+  - Sitemaps: Child sitemap entries from a <sitemapindex> document.
+*/
+type Sitemap struct {
+	URLs     []SitemapEntry
+	Sitemaps []SitemapEntry
+}
+
+// sitemapXML mirrors both the <urlset> and <sitemapindex> formats.
+type sitemapXML struct {
+	URLs []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"sitemap"`
+}
+
+/*
+ParseSitemap parses a sitemap or sitemap index document.
+
+Parameters:
+  - content: The raw document. Gzip-compressed content (e.g. sitemap.xml.gz) is
+    detected by its magic bytes and decompressed transparently.
+
+Returns:
+  - The parsed Sitemap.
+  - An error if the content is, or decompresses to, more than maxSitemapSize bytes,
+    cannot be decompressed, or is not valid XML.
+*/
+func ParseSitemap(content []byte) (*Sitemap, error) {
+	if len(content) >= 2 && content[0] == 0x1f && content[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip sitemap: %v", err)
+		}
+		defer zr.Close()
+		if content, err = io.ReadAll(io.LimitReader(zr, maxSitemapSize+1)); err != nil {
+			return nil, fmt.Errorf("invalid gzip sitemap: %v", err)
+		}
+		if len(content) > maxSitemapSize {
+			return nil, fmt.Errorf("gzip sitemap exceeds %d bytes uncompressed", maxSitemapSize)
+		}
+	} else if len(content) > maxSitemapSize {
+		return nil, fmt.Errorf("sitemap exceeds %d bytes", maxSitemapSize)
+	}
+
+	var doc sitemapXML
+	if err := xml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("invalid sitemap XML: %v", err)
+	}
+
+	sm := &Sitemap{}
+	for _, u := range doc.URLs {
+		if loc := strings.TrimSpace(u.Loc); loc != "" {
+			sm.URLs = append(sm.URLs, SitemapEntry{Loc: loc, LastMod: parseLastmod(u.LastMod)})
+		}
+	}
+	for _, s := range doc.Sitemaps {
+		if loc := strings.TrimSpace(s.Loc); loc != "" {
+			sm.Sitemaps = append(sm.Sitemaps, SitemapEntry{Loc: loc, LastMod: parseLastmod(s.LastMod)})
+		}
+	}
+	return sm, nil
+}
+
+// parseLastmod parses a W3C Datetime value, returning the zero time if it is invalid.
+func parseLastmod(value string) time.Time {
+	t, _ := config.ParseLastmod(value)
+	return t
+}
+
+/*
+SitemapURLs discovers sitemaps for the configured site and returns the page URLs they list.
+
+Parameters:
+  - ctx: Cancels sitemap fetching.
+  - skipped: Called with each sitemap that failed to fetch or parse; may be nil.
+
+Returns:
+  - Normalized page URLs on the base host that pass the route patterns and the
+    URL.Sitemaps.LastmodAfter filter, without duplicates.
+  - An error if the base URL or URL.Sitemaps.LastmodAfter is invalid.
+
+Notes:
+  - Sitemaps come from URL.Sitemaps.URLs when set; otherwise from the Sitemap lines
+    of robots.txt (unless robots.txt is ignored), falling back to /sitemap.xml.
+  - Nested sitemap indexes are followed up to maxSitemapDepth levels. Child sitemaps
+    whose own lastmod predates the cutoff are skipped.
+  - Entries without a lastmod are always kept. Sitemaps that fail to fetch or parse
+    are skipped and reported through skipped; the rest are still used.
+*/
+func (c *Crawler) SitemapURLs(ctx context.Context, skipped func(*SitemapError)) ([]string, error) {
+	base, err := c.baseURL()
+	if err != nil {
+		return nil, err
+	}
+	var cutoff time.Time
+	if c.sitemapLastmodAfter != "" {
+		if cutoff = parseLastmod(c.sitemapLastmodAfter); cutoff.IsZero() {
+			return nil, fmt.Errorf("invalid sitemap lastmodAfter %q: expected a W3C datetime such as 2024-01-31", c.sitemapLastmodAfter)
+		}
+	}
+
+	var queue []string
+	for _, s := range c.sitemapURLs {
+		if ref, err := url.Parse(strings.TrimSpace(s)); err == nil {
+			queue = append(queue, base.ResolveReference(ref).String())
+		}
+	}
+	if len(queue) == 0 && !c.ignoreRobots {
+		queue = append(queue, c.robotsFor(ctx, base.String()).Sitemaps...)
+	}
+	if len(queue) == 0 {
+		queue = append(queue, base.ResolveReference(&url.URL{Path: "/sitemap.xml"}).String())
+	}
+
+	var pages []string
+	seenPages := make(map[string]bool)
+	seenMaps := make(map[string]bool)
+	for depth := 0; depth < maxSitemapDepth && len(queue) > 0 && ctx.Err() == nil; depth++ {
+		var next []string
+		for _, loc := range queue {
+			if seenMaps[loc] {
+				continue
+			}
+			seenMaps[loc] = true
+
+			content, err := c.FetchURLContext(ctx, loc)
+			var sm *Sitemap
+			if err == nil {
+				sm, err = ParseSitemap([]byte(content))
+			}
+			if err != nil {
+				if skipped != nil && ctx.Err() == nil {
+					skipped(&SitemapError{URL: loc, Err: err})
+				}
+				continue
+			}
+
+			for _, child := range sm.Sitemaps {
+				if !isBefore(child.LastMod, cutoff) {
+					next = append(next, child.Loc)
+				}
+			}
+			for _, entry := range sm.URLs {
+				if isBefore(entry.LastMod, cutoff) {
+					continue
+				}
+				u, err := url.Parse(entry.Loc)
+				if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+					continue
+				}
+				link := NormalizeURL(u)
+				if seenPages[link] || !sameHost(base, link) || !c.followable(link) {
+					continue
+				}
+				seenPages[link] = true
+				pages = append(pages, link)
+			}
+		}
+		queue = next
+	}
+	return pages, nil
+}
+
+// isBefore reports whether a known lastmod falls before a set cutoff.
+func isBefore(lastmod, cutoff time.Time) bool {
+	return !cutoff.IsZero() && !lastmod.IsZero() && lastmod.Before(cutoff)
+}
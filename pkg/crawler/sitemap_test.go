@@ -0,0 +1,270 @@
+// File: pkg/crawler/sitemap_test.go
+
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+)
+
+// gzipBytes compresses s for serving as a .xml.gz sitemap.
+func gzipBytes(t *testing.T, s string) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(s)); err != nil {
+		t.Fatalf("Failed to gzip: %v", err)
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+// TestParseSitemap covers urlset, sitemapindex, gzip and invalid documents.
+func TestParseSitemap(t *testing.T) {
+	urlset := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc> https://example.com/a </loc><lastmod>2024-03-01</lastmod></url>
+  <url><loc>https://example.com/b</loc><lastmod>2024-03-01T10:30:00+02:00</lastmod></url>
+  <url><loc>https://example.com/c</loc></url>
+  <url><loc></loc></url>
+</urlset>`
+
+	sm, err := ParseSitemap([]byte(urlset))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(sm.URLs) != 3 || len(sm.Sitemaps) != 0 {
+		t.Fatalf("Expected 3 URLs and no sitemaps, got %+v", sm)
+	}
+	if sm.URLs[0].Loc != "https://example.com/a" || !sm.URLs[0].LastMod.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected first entry: %+v", sm.URLs[0])
+	}
+	if !sm.URLs[1].LastMod.Equal(time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected timezone-aware lastmod, got %v", sm.URLs[1].LastMod)
+	}
+	if !sm.URLs[2].LastMod.IsZero() {
+		t.Errorf("Expected missing lastmod to be zero, got %v", sm.URLs[2].LastMod)
+	}
+
+	index := `<sitemapindex><sitemap><loc>https://example.com/s1.xml</loc><lastmod>2023</lastmod></sitemap></sitemapindex>`
+	sm, err = ParseSitemap(gzipBytes(t, index))
+	if err != nil {
+		t.Fatalf("Unexpected error for gzip index: %v", err)
+	}
+	if len(sm.Sitemaps) != 1 || sm.Sitemaps[0].Loc != "https://example.com/s1.xml" || sm.Sitemaps[0].LastMod.Year() != 2023 {
+		t.Errorf("Unexpected index entries: %+v", sm.Sitemaps)
+	}
+
+	if _, err := ParseSitemap([]byte("<urlset><url>")); err == nil {
+		t.Error("Expected error for truncated XML")
+	}
+	if _, err := ParseSitemap([]byte{0x1f, 0x8b, 0x00}); err == nil {
+		t.Error("Expected error for corrupt gzip")
+	}
+	if _, err := ParseSitemap(gzipBytes(t, "<urlset>"+strings.Repeat(" ", maxSitemapSize)+"</urlset>")); err == nil {
+		t.Error("Expected error for a gzip sitemap over the size limit")
+	}
+	if _, err := ParseSitemap(make([]byte, maxSitemapSize+1)); err == nil {
+		t.Error("Expected error for a sitemap over the size limit")
+	}
+}
+
+// TestParseLastmod verifies the supported W3C Datetime forms.
+func TestParseLastmod(t *testing.T) {
+	cases := map[string]time.Time{
+		"2024":                      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		"2024-05":                   time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		"2024-05-06":                time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC),
+		"2024-05-06T07:08Z":         time.Date(2024, 5, 6, 7, 8, 0, 0, time.UTC),
+		"2024-05-06T07:08:09.5Z":    time.Date(2024, 5, 6, 7, 8, 9, 500000000, time.UTC),
+		"2024-05-06T07:08:09-05:00": time.Date(2024, 5, 6, 12, 8, 9, 0, time.UTC),
+		"not a date":                {},
+		"":                          {},
+	}
+	for in, expected := range cases {
+		if got := parseLastmod(in); !got.Equal(expected) {
+			t.Errorf("parseLastmod(%q): expected %v, got %v", in, expected, got)
+		}
+	}
+}
+
+// newSitemapServer serves robots.txt, a gzipped sitemap index and two child sitemaps.
+func newSitemapServer(t *testing.T) *httptest.Server {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u := srv.URL
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprintf(w, "User-agent: *\nSitemap: %s/index.xml.gz\n", u)
+		case "/index.xml.gz":
+			w.Write(gzipBytes(t, fmt.Sprintf(`<sitemapindex>
+				<sitemap><loc>%[1]s/new.xml</loc><lastmod>2024-06-01</lastmod></sitemap>
+				<sitemap><loc>%[1]s/old.xml</loc><lastmod>2020-01-01</lastmod></sitemap>
+				<sitemap><loc>%[1]s/index.xml.gz</loc></sitemap>
+			</sitemapindex>`, u)))
+		case "/new.xml":
+			fmt.Fprintf(w, `<urlset>
+				<url><loc>%[1]s/fresh</loc><lastmod>2024-05-01</lastmod></url>
+				<url><loc>%[1]s/stale</loc><lastmod>2021-01-01</lastmod></url>
+				<url><loc>%[1]s/undated</loc></url>
+				<url><loc>https://other.example/offsite</loc></url>
+				<url><loc>%[1]s/fresh#dup</loc></url>
+			</urlset>`, u)
+		case "/old.xml":
+			fmt.Fprintf(w, `<urlset><url><loc>%s/archived</loc></url></urlset>`, u)
+		case "/sitemap.xml":
+			fmt.Fprintf(w, `<urlset><url><loc>%s/from-default</loc></url></urlset>`, u)
+		case "/custom.xml":
+			fmt.Fprintf(w, `<urlset><url><loc>%s/from-custom</loc></url></urlset>`, u)
+		case "/broken.xml":
+			fmt.Fprint(w, "<urlset><url>")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return srv
+}
+
+// newSitemapCrawler builds a Crawler for srv with sitemap seeding enabled.
+func newSitemapCrawler(base string, mutate func(cfg *config.Config)) *Crawler {
+	cfg := &config.Config{}
+	cfg.URL.Base = base
+	cfg.URL.Sitemaps.Enabled = true
+	cfg.ScrapingOptions.Concurrency = 1
+	cfg.ApplyDefaults()
+	cfg.ScrapingOptions.RateLimit = 0
+	cfg.ScrapingOptions.RetryAttempts = 0
+	if mutate != nil {
+		mutate(cfg)
+	}
+	return New(cfg)
+}
+
+// TestSitemapURLs verifies discovery via robots.txt, nested gzip indexes,
+// lastmod filtering, explicit sitemap URLs and the /sitemap.xml fallback.
+func TestSitemapURLs(t *testing.T) {
+	srv := newSitemapServer(t)
+	defer srv.Close()
+
+	strip := func(urls []string) []string {
+		var out []string
+		for _, u := range urls {
+			out = append(out, strings.TrimPrefix(u, srv.URL))
+		}
+		return out
+	}
+
+	cases := []struct {
+		desc      string
+		mutate    func(cfg *config.Config)
+		expected  []string
+		skipped   []string
+		expectErr bool
+	}{
+		{
+			desc:     "Robots sitemaps without a cutoff",
+			expected: []string{"/fresh", "/stale", "/undated", "/archived"},
+		},
+		{
+			desc: "Lastmod cutoff skips old entries and old child sitemaps",
+			mutate: func(cfg *config.Config) {
+				cfg.URL.Sitemaps.LastmodAfter = "2023-01-01"
+			},
+			expected: []string{"/fresh", "/undated"},
+		},
+		{
+			desc: "Explicit sitemap URLs replace discovery",
+			mutate: func(cfg *config.Config) {
+				cfg.URL.Sitemaps.URLs = []string{"/custom.xml"}
+			},
+			expected: []string{"/from-custom"},
+		},
+		{
+			desc: "Missing and invalid sitemaps are reported and skipped",
+			mutate: func(cfg *config.Config) {
+				cfg.URL.Sitemaps.URLs = []string{"/gone.xml", "/broken.xml", "/custom.xml"}
+			},
+			expected: []string{"/from-custom"},
+			skipped:  []string{"/gone.xml", "/broken.xml"},
+		},
+		{
+			desc: "Falls back to /sitemap.xml when robots.txt is ignored",
+			mutate: func(cfg *config.Config) {
+				cfg.ScrapingOptions.IgnoreRobots = true
+			},
+			expected: []string{"/from-default"},
+		},
+		{
+			desc: "Route patterns filter sitemap URLs",
+			mutate: func(cfg *config.Config) {
+				cfg.URL.Routes = []string{"/fr*"}
+			},
+			expected: []string{"/fresh"},
+		},
+		{
+			desc: "Invalid cutoff is an error",
+			mutate: func(cfg *config.Config) {
+				cfg.URL.Sitemaps.LastmodAfter = "yesterday"
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := newSitemapCrawler(srv.URL, tc.mutate)
+			var skipped []string
+			urls, err := c.SitemapURLs(context.Background(), func(e *SitemapError) {
+				skipped = append(skipped, strings.TrimPrefix(e.URL, srv.URL))
+			})
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("Expected error, got %v", urls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := strip(urls); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+			if !reflect.DeepEqual(skipped, tc.skipped) {
+				t.Errorf("Expected skipped sitemaps %v, got %v", tc.skipped, skipped)
+			}
+		})
+	}
+}
+
+// TestCrawlSitemapSeeds verifies that sitemap pages join the crawl frontier.
+func TestCrawlSitemapSeeds(t *testing.T) {
+	srv := newSitemapServer(t)
+	defer srv.Close()
+
+	c := newSitemapCrawler(srv.URL, func(cfg *config.Config) {
+		cfg.URL.Sitemaps.URLs = []string{"/custom.xml"}
+		cfg.ScrapingOptions.MaxDepth = 1
+	})
+	var visited []string
+	if err := c.Crawl(context.Background(), func(p Page) { visited = append(visited, strings.TrimPrefix(p.URL, srv.URL)) }); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"/", "/from-custom"}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("Expected %v, got %v", expected, visited)
+	}
+
+	bad := newSitemapCrawler(srv.URL, func(cfg *config.Config) { cfg.URL.Sitemaps.LastmodAfter = "bad" })
+	if err := bad.Crawl(context.Background(), func(Page) {}); err == nil {
+		t.Error("Expected Crawl to fail with an invalid lastmod cutoff")
+	}
+}
@@ -0,0 +1,289 @@
+// File: pkg/parser/dates.go
+
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// namedLayouts lets date layouts refer to the time package's predefined formats by name.
+var namedLayouts = map[string]string{
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"ANSIC":       time.ANSIC,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+}
+
+// defaultDateLayouts are tried after any configured layouts.
+var defaultDateLayouts = []string{
+	time.RFC3339, time.RFC1123Z, time.RFC1123, time.RFC850, time.ANSIC,
+	time.DateTime, time.DateOnly, "2006-01-02T15:04:05", "2006-01-02T15:04",
+	"2006/01/02", "02.01.2006", "2.1.2006",
+	"January 2, 2006", "Jan 2, 2006", "Monday, January 2, 2006",
+	"January 2, 2006 15:04", "January 2, 2006 3:04 PM", "2 January 2006", "2 Jan 2006",
+}
+
+// normalizedLayouts are tried against dates rewritten by normalizeDate.
+var normalizedLayouts = func() []string {
+	var layouts []string
+	for _, d := range []string{"January 2 2006", "2 January 2006", "January 2006", "2006 January 2"} {
+		for _, t := range []string{"", " 15:04", " 15:04:05", " 3:04pm", " 3:04 pm"} {
+			for _, z := range []string{"", " MST", " -0700"} {
+				layouts = append(layouts, d+t+z)
+			}
+		}
+	}
+	return layouts
+}()
+
+// monthNames maps localized month names and abbreviations to English.
+var monthNames = map[string]string{}
+
+func init() {
+	months := [][]string{
+		{"january", "jan", "januar", "jänner", "janvier", "janv", "enero", "ene", "gennaio", "gen", "janeiro", "januari"},
+		{"february", "feb", "februar", "février", "fevrier", "févr", "fevr", "febrero", "febbraio", "fevereiro", "fev", "februari"},
+		{"march", "mar", "märz", "maerz", "mär", "mrz", "mars", "marzo", "março", "marco", "maart", "mrt"},
+		{"april", "apr", "avril", "avr", "abril", "abr", "aprile"},
+		{"may", "mai", "mayo", "maggio", "mag", "maio", "mei"},
+		{"june", "jun", "juni", "juin", "junio", "giugno", "giu", "junho"},
+		{"july", "jul", "juli", "juillet", "juil", "julio", "luglio", "lug", "julho"},
+		{"august", "aug", "août", "aout", "agosto", "ago", "augustus"},
+		{"september", "sep", "sept", "septembre", "septiembre", "setembro", "set", "settembre"},
+		{"october", "oct", "oktober", "okt", "octobre", "octubre", "ottobre", "ott", "outubro", "out"},
+		{"november", "nov", "novembre", "noviembre", "novembro"},
+		{"december", "dec", "dezember", "dez", "décembre", "decembre", "déc", "diciembre", "dic", "dicembre", "dezembro", "desember"},
+	}
+	for _, names := range months {
+		for _, name := range names {
+			monthNames[name] = names[0]
+		}
+	}
+}
+
+// dateNoise are words dropped when normalizing a date: weekdays and connectors.
+var dateNoise = map[string]bool{
+	"monday": true, "tuesday": true, "wednesday": true, "thursday": true, "friday": true, "saturday": true, "sunday": true,
+	"mon": true, "tue": true, "tues": true, "wed": true, "thu": true, "thur": true, "thurs": true, "fri": true, "sat": true, "sun": true,
+	"montag": true, "dienstag": true, "mittwoch": true, "donnerstag": true, "freitag": true, "samstag": true, "sonntag": true,
+	"lundi": true, "mardi": true, "mercredi": true, "jeudi": true, "vendredi": true, "samedi": true, "dimanche": true,
+	"lunes": true, "martes": true, "miércoles": true, "miercoles": true, "jueves": true, "viernes": true, "sábado": true, "sabado": true, "domingo": true,
+	"lunedì": true, "martedì": true, "mercoledì": true, "giovedì": true, "venerdì": true, "sabato": true, "domenica": true,
+	"of": true, "the": true, "at": true, "on": true, "de": true, "del": true, "um": true, "à": true, "le": true, "el": true,
+	"published": true, "updated": true, "posted": true,
+}
+
+// ordinalSuffix matches day numbers such as "3rd", "1er" or "3.".
+var ordinalSuffix = regexp.MustCompile(`^(\d{1,2})(?:st|nd|rd|th|er|e|º|ª|\.)$`)
+
+/*
+DateParser normalizes dates in the many formats sites publish them in.
+
+Fields:
+  - layouts: Go time layouts tried in order before the built-in heuristics.
+  - location: The zone assumed for dates that carry no offset.
+
+Usage:
+
+	dp, err := NewDateParser([]string{"02/01/2006"}, "Europe/Berlin")
+	if err != nil {
+	    // Handle error
+	}
+	t, err := dp.Parse("3 days ago", fetchedAt)
+*/
+type DateParser struct {
+	layouts  []string
+	location *time.Location
+}
+
+/*
+NewDateParser creates a DateParser.
+
+Parameters:
+  - layouts: Go time layouts, or names such as "RFC3339", tried before the defaults.
+  - timezone: An IANA zone name (e.g. "Europe/Berlin") for dates without an offset;
+    empty means UTC.
+
+Returns:
+  - The parser, or an error if timezone is unknown.
+*/
+func NewDateParser(layouts []string, timezone string) (*DateParser, error) {
+	location := time.UTC
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("unknown timezone %q: %v", timezone, err)
+		}
+		location = loc
+	}
+	dp := &DateParser{location: location}
+	for _, l := range layouts {
+		if named, ok := namedLayouts[l]; ok {
+			l = named
+		}
+		dp.layouts = append(dp.layouts, l)
+	}
+	dp.layouts = append(dp.layouts, defaultDateLayouts...)
+	return dp, nil
+}
+
+/*
+Parse converts value to a UTC time.
+
+Parameters:
+  - value: The date text, e.g. "2024-03-01T10:00:00+02:00", "Fri, 01 Mar 2024 10:00:00 GMT",
+    "3 days ago", "yesterday", "1. März 2024" or "3rd of March, 2024".
+  - ref: The time relative dates are resolved against, normally when the page was fetched.
+
+Returns:
+  - The parsed time in UTC.
+  - An error if no layout or heuristic matches.
+
+Notes:
+  - Configured layouts are tried first, then RFC 3339/1123 and other common layouts, then
+    relative expressions (English, German, French and Spanish), then a normalized form with
+    localized month names translated and weekdays and ordinal suffixes removed.
+*/
+func (dp *DateParser) Parse(value string, ref time.Time) (time.Time, error) {
+	s := strings.TrimSpace(value)
+	if s == "" {
+		return time.Time{}, fmt.Errorf("empty date")
+	}
+	for _, layout := range dp.layouts {
+		if t, err := time.ParseInLocation(layout, s, dp.location); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	if t, ok := dp.parseRelative(s, ref); ok {
+		return t.UTC(), nil
+	}
+	normalized := normalizeDate(s)
+	for _, layout := range normalizedLayouts {
+		if t, err := time.ParseInLocation(layout, normalized, dp.location); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q as a date", value)
+}
+
+// relativePatterns capture a count and unit from "3 days ago" style expressions.
+var relativePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^(\d+|an?|one)\s*(\pL+)\s+ago$`),
+	regexp.MustCompile(`^vor\s+(\d+|einer?|einem)\s+(\pL+)$`),
+	regexp.MustCompile(`^il\s+y\s+a\s+(\d+|une?)\s+(\pL+)$`),
+	regexp.MustCompile(`^hace\s+(\d+|una?)\s+(\pL+)$`),
+}
+
+// relativeDays maps words for the current and previous day to a day offset.
+var relativeDays = map[string]int{
+	"today": 0, "heute": 0, "aujourd'hui": 0, "hoy": 0, "oggi": 0,
+	"yesterday": -1, "gestern": -1, "hier": -1, "ayer": -1, "ieri": -1,
+}
+
+// parseRelative resolves expressions such as "now", "yesterday" or "3 hours ago" against ref.
+func (dp *DateParser) parseRelative(s string, ref time.Time) (time.Time, bool) {
+	if ref.IsZero() {
+		ref = time.Now()
+	}
+	s = strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	switch s {
+	case "now", "just now", "right now", "jetzt", "gerade eben", "à l'instant", "ahora":
+		return ref, true
+	}
+	if offset, ok := relativeDays[s]; ok {
+		y, m, d := ref.In(dp.location).Date()
+		return time.Date(y, m, d+offset, 0, 0, 0, 0, dp.location), true
+	}
+
+	for _, re := range relativePatterns {
+		m := re.FindStringSubmatch(s)
+		if m == nil {
+			continue
+		}
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			n = 1 // "a", "an", "einer", "une", ...
+		}
+		switch unit := relativeUnit(m[2]); unit {
+		case "month":
+			return ref.AddDate(0, -n, 0), true
+		case "year":
+			return ref.AddDate(-n, 0, 0), true
+		case "":
+			return time.Time{}, false
+		default:
+			return ref.Add(-time.Duration(n) * relativeDurations[unit]), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// relativeDurations are the fixed-length units of relative dates.
+var relativeDurations = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+}
+
+// relativeUnitStems map the leading letters of localized (and plural) unit words to units.
+// They are checked in order, so longer stems that share a prefix come first.
+var relativeUnitStems = []struct{ stem, unit string }{
+	{"month", "month"}, {"monat", "month"}, {"mois", "month"}, {"mes", "month"},
+	{"min", "minute"},
+	{"sec", "second"}, {"sek", "second"}, {"seg", "second"},
+	{"semaine", "week"}, {"semana", "week"}, {"week", "week"}, {"woche", "week"}, {"wk", "week"},
+	{"hour", "hour"}, {"heure", "hour"}, {"hora", "hour"}, {"stunde", "hour"}, {"hr", "hour"},
+	{"day", "day"}, {"tag", "day"}, {"jour", "day"}, {"día", "day"}, {"dia", "day"},
+	{"year", "year"}, {"jahr", "year"}, {"année", "year"}, {"an", "year"}, {"año", "year"}, {"ano", "year"}, {"yr", "year"},
+}
+
+// relativeUnitAbbreviations are single-letter units as in "5h ago".
+var relativeUnitAbbreviations = map[string]string{
+	"s": "second", "m": "minute", "h": "hour", "d": "day", "w": "week", "mo": "month", "y": "year",
+}
+
+// relativeUnit returns the unit a word names, or "" if it is not a unit.
+func relativeUnit(word string) string {
+	if unit, ok := relativeUnitAbbreviations[word]; ok {
+		return unit
+	}
+	for _, u := range relativeUnitStems {
+		if strings.HasPrefix(word, u.stem) {
+			return u.unit
+		}
+	}
+	return ""
+}
+
+// normalizeDate lowercases s, translates month names to English, and drops weekdays,
+// connectors, punctuation and ordinal suffixes, e.g. "Lundi 3 mars 2025" -> "3 march 2025".
+func normalizeDate(s string) string {
+	s = strings.NewReplacer(",", " ", "/", " ", "(", " ", ")", " ").Replace(strings.ToLower(s))
+	var tokens []string
+	for _, token := range strings.Fields(s) {
+		if m := ordinalSuffix.FindStringSubmatch(token); m != nil {
+			token = m[1]
+		}
+		token = strings.TrimSuffix(token, ".")
+		if dateNoise[token] {
+			continue
+		}
+		if month, ok := monthNames[token]; ok {
+			token = month
+		}
+		tokens = append(tokens, token)
+	}
+	return strings.Join(tokens, " ")
+}
@@ -0,0 +1,129 @@
+// File: pkg/parser/dates_test.go
+
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+)
+
+// TestDateParser verifies absolute, localized and relative date parsing.
+func TestDateParser(t *testing.T) {
+	ref := time.Date(2025, 3, 10, 15, 30, 0, 0, time.UTC)
+	cases := []struct {
+		desc      string
+		layouts   []string
+		timezone  string
+		input     string
+		expected  time.Time
+		expectErr bool
+	}{
+		{desc: "RFC 3339 with offset", input: "2025-03-03T10:00:00+02:00", expected: time.Date(2025, 3, 3, 8, 0, 0, 0, time.UTC)},
+		{desc: "RFC 1123", input: "Mon, 03 Mar 2025 10:00:00 GMT", expected: time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC)},
+		{desc: "date only", input: " 2025-03-03 ", expected: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{desc: "configured layout", layouts: []string{"02/01/2006"}, input: "03/04/2025", expected: time.Date(2025, 4, 3, 0, 0, 0, 0, time.UTC)},
+		{desc: "named layout", layouts: []string{"RFC822"}, input: "03 Mar 25 10:00 UTC", expected: time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC)},
+		{desc: "timezone applies to dates without offset", timezone: "Europe/Berlin", input: "2025-03-03 10:00:00", expected: time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)},
+		{desc: "timezone ignored when an offset is present", timezone: "Europe/Berlin", input: "2025-03-03T10:00:00Z", expected: time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC)},
+		{desc: "ordinal and weekday", input: "Monday, 3rd of March, 2025", expected: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{desc: "abbreviated month with time", input: "Mar. 3, 2025 at 4:05 PM", expected: time.Date(2025, 3, 3, 16, 5, 0, 0, time.UTC)},
+		{desc: "German", input: "Montag, 3. März 2025", expected: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{desc: "French", input: "1er avril 2025 14:30", expected: time.Date(2025, 4, 1, 14, 30, 0, 0, time.UTC)},
+		{desc: "Spanish", input: "3 de marzo de 2025", expected: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{desc: "month and year", input: "September 2024", expected: time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)},
+		{desc: "just now", input: "Just now", expected: ref},
+		{desc: "yesterday", input: "Yesterday", expected: time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC)},
+		{desc: "today in a timezone", timezone: "America/New_York", input: "today", expected: time.Date(2025, 3, 10, 4, 0, 0, 0, time.UTC)},
+		{desc: "hours ago", input: "3 hours ago", expected: ref.Add(-3 * time.Hour)},
+		{desc: "an hour ago", input: "an hour ago", expected: ref.Add(-time.Hour)},
+		{desc: "abbreviated unit", input: "5d ago", expected: ref.AddDate(0, 0, -5)},
+		{desc: "months ago", input: "2 months ago", expected: ref.AddDate(0, -2, 0)},
+		{desc: "German relative", input: "vor 3 Tagen", expected: ref.AddDate(0, 0, -3)},
+		{desc: "French relative", input: "il y a 2 semaines", expected: ref.AddDate(0, 0, -14)},
+		{desc: "Spanish relative", input: "hace 1 año", expected: ref.AddDate(-1, 0, 0)},
+		{desc: "unknown unit", input: "3 fortnights ago", expectErr: true},
+		{desc: "not a date", input: "coming soon", expectErr: true},
+		{desc: "empty", input: "  ", expectErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			dp, err := NewDateParser(tc.layouts, tc.timezone)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got, err := dp.Parse(tc.input, ref)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("Expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !got.Equal(tc.expected) || got.Location() != time.UTC {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestNewDateParserUnknownTimezone verifies that an invalid timezone is rejected.
+func TestNewDateParserUnknownTimezone(t *testing.T) {
+	if _, err := NewDateParser(nil, "Mars/Olympus"); err == nil {
+		t.Error("Expected error for unknown timezone")
+	}
+}
+
+// TestPostProcessorDates verifies that datePublished is normalized against the fetch time
+// using DataFormatting.DateLayouts, and that unparseable dates keep their original text.
+func TestPostProcessorDates(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ParseRules.DatePublished = "time"
+	cfg.DataFormatting.DateLayouts = []string{"02/01/2006"}
+	cfg.ParseRules.Fields = map[string]config.FieldRule{
+		"updated": {Selector: ".updated", Transforms: []config.TransformSpec{{Name: "toDate"}}},
+		"comment": {Selector: ".comment time", Multiple: true, Transforms: []config.TransformSpec{{Name: "toDate"}}},
+	}
+	pp, err := NewPostProcessor(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	fetched := time.Date(2025, 3, 10, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	record := &Record{
+		FetchedAt: fetched,
+		Fields: map[string]interface{}{
+			"datePublished": "03/04/2025",
+			"updated":       "2 hours ago",
+			"comment":       []string{"yesterday", "sometime"},
+		},
+	}
+	if err := pp.Process(record); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := record.Fields["datePublished"]; got != time.Date(2025, 4, 3, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("Unexpected datePublished %#v", got)
+	}
+	if got := record.Fields["updated"]; got != time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC) {
+		t.Errorf("Unexpected updated %#v", got)
+	}
+	comments, ok := record.Fields["comment"].([]interface{})
+	if !ok || len(comments) != 2 || comments[0] != time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC) || comments[1] != "sometime" {
+		t.Errorf("Unexpected comment %#v", record.Fields["comment"])
+	}
+}
+
+// TestNewPostProcessorInvalidTimezone verifies that a bad DataFormatting.Timezone is reported.
+func TestNewPostProcessorInvalidTimezone(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ParseRules.DatePublished = "time"
+	cfg.DataFormatting.Timezone = "Nowhere/Special"
+	if _, err := NewPostProcessor(cfg); err == nil {
+		t.Error("Expected error for unknown timezone")
+	}
+}
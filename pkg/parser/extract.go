@@ -0,0 +1,344 @@
+// File: pkg/parser/extract.go
+
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+)
+
+// urlAttributes are attributes whose values are resolved against the document base URL.
+var urlAttributes = map[string]bool{
+	"href":   true,
+	"src":    true,
+	"action": true,
+	"poster": true,
+}
+
+/*
+extractor evaluates FieldRules against a parsed document.
+
+Fields:
+  - base: The URL relative href/src values are resolved against; nil leaves them as-is.
+  - structured: The page's structured data, consulted by rule Fallback references.
+  - doc: The parsed page, scored for the "readability" Fallback reference.
+  - content: The page's main content, computed on first use (see MainContent).
+*/
+type extractor struct {
+	cfg        *config.Config
+	base       *url.URL
+	structured *StructuredData
+	doc        *goquery.Document
+	content    *string
+}
+
+// missingFieldError reports a Required field that matched nothing.
+type missingFieldError struct {
+	name     string
+	selector string
+}
+
+func (e *missingFieldError) Error() string {
+	return fmt.Sprintf("required field %s not found using selector %q", e.name, e.selector)
+}
+
+/*
+match is a single result of evaluating a selector.
+
+Fields:
+  - sel: The matched element, or nil when XPath selected a value directly.
+  - value: The selected value when sel is nil (an attribute or text node, or a scalar result).
+  - attribute: The attribute name when value came from an attribute node.
+*/
+type match struct {
+	sel       *goquery.Selection
+	value     string
+	attribute string
+}
+
+/*
+extractFields evaluates every rule within root.
+
+Parameters:
+  - root: The selection rules are scoped to (the document, or a matched parent element).
+  - rules: The rules to evaluate, keyed by field name.
+  - nested: Whether these are nested rules, in which an empty selector targets root itself.
+
+Returns:
+  - The extracted values keyed by field name, omitting missing optional fields.
+  - An error if a selector is invalid or a Required field is missing.
+*/
+func (ex *extractor) extractFields(root *goquery.Selection, rules map[string]config.FieldRule, nested bool) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	for _, name := range sortedNames(rules) {
+		value, err := ex.extractField(root, name, rules[name], nested)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			fields[name] = value
+		}
+	}
+	return fields, nil
+}
+
+// extractField evaluates a single rule within root, returning nil if the field is absent.
+func (ex *extractor) extractField(root *goquery.Selection, name string, rule config.FieldRule, nested bool) (interface{}, error) {
+	selector, attribute := rule.Target()
+
+	var matches []match
+	switch {
+	case selector == "" && nested:
+		matches = elementMatches(root)
+	case selector == "" && len(rule.Fallback) == 0:
+		return nil, fmt.Errorf("field %s has no selector", name)
+	case selector == "":
+	case rule.Type == config.SelectorXPath:
+		expr, err := xpath.Compile(selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid XPath expression for %s %q: %v", name, rule.Selector, err)
+		}
+		matches = xpathMatches(root, expr)
+	case rule.Type == "" || rule.Type == config.SelectorCSS || rule.Type == config.RuleTable:
+		matcher, err := cascadia.Compile(selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector for %s %q: %v", name, rule.Selector, err)
+		}
+		matches = elementMatches(root.FindMatcher(matcher))
+	default:
+		return nil, fmt.Errorf("field %s has unknown selector type %q", name, rule.Type)
+	}
+	if rule.Type == config.RuleTable {
+		return extractTables(name, rule, matches)
+	}
+
+	markup := keepsMarkup(ex.cfg, name, rule, nested)
+	var values []interface{}
+	for _, m := range matches {
+		value, err := ex.extractValue(m, attribute, rule, markup)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if value == nil {
+			continue
+		}
+		values = append(values, value)
+		if !rule.Multiple {
+			break
+		}
+	}
+
+	for _, ref := range rule.Fallback {
+		if len(values) > 0 {
+			break
+		}
+		for _, value := range ex.lookup(ref) {
+			values = append(values, value)
+			if !rule.Multiple {
+				break
+			}
+		}
+	}
+
+	if len(values) == 0 {
+		switch {
+		case rule.Default != "":
+			values = []interface{}{rule.Default}
+		case rule.Required:
+			return nil, &missingFieldError{name: name, selector: rule.Selector}
+		default:
+			return nil, nil
+		}
+	}
+	if !rule.Multiple {
+		return values[0], nil
+	}
+	return collect(values), nil
+}
+
+// extractTables returns the rows of the first matched table, or of every matched table
+// with Multiple. A match that is not a <table> contributes the first table inside it.
+func extractTables(name string, rule config.FieldRule, matches []match) (interface{}, error) {
+	var rows []map[string]interface{}
+	for _, m := range matches {
+		table := m.sel
+		if goquery.NodeName(table) != "table" {
+			table = table.Find("table").First()
+		}
+		if table.Length() == 0 {
+			continue
+		}
+		tableData, err := extractTable(table.Get(0))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		rows = append(rows, tableData...)
+		if len(rows) > 0 && !rule.Multiple {
+			break
+		}
+	}
+	if len(rows) == 0 {
+		if rule.Required {
+			return nil, &missingFieldError{name: name, selector: rule.Selector}
+		}
+		return nil, nil
+	}
+	return rows, nil
+}
+
+// lookup resolves a Fallback reference: the page's main content for "readability",
+// otherwise the structured data it names.
+func (ex *extractor) lookup(ref string) []string {
+	if strings.TrimSpace(ref) != config.ReadabilityReference {
+		return ex.structured.Lookup(ref)
+	}
+	if ex.content == nil {
+		content := ""
+		if ex.doc != nil {
+			content = mainContent(ex.doc, ex.base)
+		}
+		ex.content = &content
+	}
+	if *ex.content == "" {
+		return nil
+	}
+	return []string{*ex.content}
+}
+
+// extractValue returns the value of a single match, or nil if it is empty. Elements yield
+// their inner HTML when markup is set, and their text otherwise. With Multiple, a nested
+// object missing a Required field is treated as empty, so only that item is dropped.
+func (ex *extractor) extractValue(m match, attribute string, rule config.FieldRule, markup bool) (interface{}, error) {
+	if m.sel == nil {
+		if len(rule.Fields) > 0 {
+			return nil, fmt.Errorf("nested fields require an element, but the selector matched a value")
+		}
+		value := ex.resolve(m.attribute, strings.TrimSpace(m.value))
+		if value == "" {
+			return nil, nil
+		}
+		return value, nil
+	}
+
+	sel := m.sel
+	if len(rule.Fields) > 0 {
+		object, err := ex.extractFields(sel, rule.Fields, true)
+		var missing *missingFieldError
+		if rule.Multiple && errors.As(err, &missing) {
+			return nil, nil
+		}
+		if err != nil || len(object) == 0 {
+			return nil, err
+		}
+		return object, nil
+	}
+
+	if attribute == "" && goquery.NodeName(sel) == "meta" {
+		attribute = "content"
+	}
+	var value string
+	if attribute != "" {
+		value, _ = sel.Attr(attribute)
+		value = ex.resolve(attribute, strings.TrimSpace(value))
+	} else if text := strings.TrimSpace(sel.Text()); text != "" && !markup {
+		value = text
+	} else if text != "" {
+		// Keep the markup for PostProcessor's stripHTML or toMarkdown step.
+		inner, err := sel.Html()
+		if err != nil {
+			return nil, err
+		}
+		value = strings.TrimSpace(inner)
+	}
+	if value == "" {
+		return nil, nil
+	}
+	return value, nil
+}
+
+// elementMatches wraps each element of sel as a match.
+func elementMatches(sel *goquery.Selection) []match {
+	matches := make([]match, 0, sel.Length())
+	for i := range sel.Nodes {
+		matches = append(matches, match{sel: sel.Eq(i)})
+	}
+	return matches
+}
+
+// xpathMatches evaluates expr against each node of root. Element nodes become element
+// matches; attribute and text nodes, and string, number or boolean results, become values.
+func xpathMatches(root *goquery.Selection, expr *xpath.Expr) []match {
+	var matches []match
+	for _, node := range root.Nodes {
+		switch result := expr.Evaluate(htmlquery.CreateXPathNavigator(node)).(type) {
+		case *xpath.NodeIterator:
+			for result.MoveNext() {
+				nav := result.Current().(*htmlquery.NodeNavigator)
+				switch nav.NodeType() {
+				case xpath.ElementNode, xpath.RootNode:
+					matches = append(matches, match{sel: goquery.NewDocumentFromNode(nav.Current()).Selection})
+				case xpath.AttributeNode:
+					matches = append(matches, match{value: nav.Value(), attribute: nav.LocalName()})
+				default:
+					matches = append(matches, match{value: nav.Value()})
+				}
+			}
+		case string:
+			matches = append(matches, match{value: result})
+		case float64:
+			matches = append(matches, match{value: strconv.FormatFloat(result, 'f', -1, 64)})
+		case bool:
+			matches = append(matches, match{value: strconv.FormatBool(result)})
+		}
+	}
+	return matches
+}
+
+// resolve makes URL-valued attributes absolute against the document base.
+func (ex *extractor) resolve(attribute, value string) string {
+	if ex.base == nil || value == "" || !urlAttributes[strings.ToLower(attribute)] {
+		return value
+	}
+	ref, err := url.Parse(value)
+	if err != nil {
+		return value
+	}
+	return ex.base.ResolveReference(ref).String()
+}
+
+// collect converts a list of values into []string or []map[string]interface{} when
+// all elements share that type, so that multi-valued fields have a concrete type.
+func collect(values []interface{}) interface{} {
+	switch values[0].(type) {
+	case string:
+		out := make([]string, 0, len(values))
+		for _, v := range values {
+			s, ok := v.(string)
+			if !ok {
+				return values
+			}
+			out = append(out, s)
+		}
+		return out
+	case map[string]interface{}:
+		out := make([]map[string]interface{}, 0, len(values))
+		for _, v := range values {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return values
+			}
+			out = append(out, m)
+		}
+		return out
+	}
+	return values
+}
@@ -0,0 +1,252 @@
+// File: pkg/parser/markdown.go
+
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// toMarkdown converts an HTML fragment to Markdown: headings, paragraphs, emphasis, links,
+// images, lists, block quotes, code and tables. Scripts, styles and other hidden elements
+// are dropped.
+func toMarkdown(value string) (string, error) {
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(value), context)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse HTML: %v", err)
+	}
+	var b strings.Builder
+	for _, n := range nodes {
+		b.WriteString(markdownNode(n))
+	}
+	return tidyMarkdown(b.String()), nil
+}
+
+// markdownChildren renders the children of n.
+func markdownChildren(n *html.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(markdownNode(c))
+	}
+	return b.String()
+}
+
+// markdownNode renders n and its descendants.
+func markdownNode(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return markdownEscaper.Replace(strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return ' '
+			}
+			return r
+		}, n.Data))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	if hiddenElements[n.Data] {
+		return ""
+	}
+
+	switch n.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := oneLine(markdownChildren(n))
+		if text == "" {
+			return ""
+		}
+		return "\n\n" + strings.Repeat("#", int(n.Data[1]-'0')) + " " + text + "\n\n"
+	case "br":
+		return "\n"
+	case "hr":
+		return "\n\n---\n\n"
+	case "strong", "b":
+		return wrapInline(markdownChildren(n), "**")
+	case "em", "i":
+		return wrapInline(markdownChildren(n), "*")
+	case "del", "s", "strike":
+		return wrapInline(markdownChildren(n), "~~")
+	case "code":
+		return wrapInline(textContent(n), "`")
+	case "pre":
+		code := strings.Trim(textContent(n), "\n")
+		return "\n\n```\n" + code + "\n```\n\n"
+	case "a":
+		text := markdownChildren(n)
+		href := attr(n, "href")
+		if strings.TrimSpace(text) == "" || href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(href, "javascript:") {
+			return text
+		}
+		return "[" + oneLine(text) + "](" + href + ")"
+	case "img":
+		src := attr(n, "src")
+		if src == "" {
+			return ""
+		}
+		return "![" + oneLine(attr(n, "alt")) + "](" + src + ")"
+	case "ul", "ol":
+		return "\n\n" + markdownList(n) + "\n\n"
+	case "blockquote":
+		lines := strings.Split(tidyMarkdown(markdownChildren(n)), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		return "\n\n" + strings.Join(lines, "\n") + "\n\n"
+	case "table":
+		return "\n\n" + markdownTable(n) + "\n\n"
+	}
+	if blockElements[n.Data] || lineElements[n.Data] {
+		return "\n\n" + strings.TrimSpace(markdownChildren(n)) + "\n\n"
+	}
+	return markdownChildren(n)
+}
+
+// markdownList renders the items of a ul or ol, indenting nested content under each marker.
+func markdownList(n *html.Node) string {
+	var items []string
+	number := 1
+	if start := attr(n, "start"); start != "" {
+		fmt.Sscan(start, &number)
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.Data != "li" {
+			continue
+		}
+		marker := "- "
+		if n.Data == "ol" {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+		lines := strings.Split(tidyMarkdown(markdownChildren(c)), "\n")
+		for i, line := range lines {
+			switch {
+			case i == 0:
+				lines[i] = marker + line
+			case line != "":
+				lines[i] = strings.Repeat(" ", len(marker)) + line
+			}
+		}
+		items = append(items, strings.Join(lines, "\n"))
+	}
+	return strings.Join(items, "\n")
+}
+
+// markdownTable renders a table as a pipe table whose first row is the header.
+func markdownTable(n *html.Node) string {
+	var rows [][]string
+	width := 0
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch {
+			case c.Type != html.ElementNode || c.Data == "table":
+			case c.Data == "tr":
+				var row []string
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+						row = append(row, strings.ReplaceAll(oneLine(markdownChildren(cell)), "|", `\|`))
+					}
+				}
+				rows = append(rows, row)
+				width = max(width, len(row))
+			default:
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	if width == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for i, row := range rows {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString(strings.Repeat("| --- ", width) + "|\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// wrapInline surrounds the trimmed text with marker, keeping the surrounding spaces outside.
+func wrapInline(text, marker string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	lead := text[:len(text)-len(strings.TrimLeft(text, " "))]
+	trail := text[len(strings.TrimRight(text, " ")):]
+	return lead + marker + trimmed + marker + trail
+}
+
+// textContent returns the raw text of n's descendants.
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+// markdownEscaper backslash-escapes characters that would otherwise read as Markdown
+// syntax in text. Code is taken verbatim from the markup and is not escaped.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "#", `\#`,
+)
+
+// attr returns the value of n's attribute key, or "".
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return strings.TrimSpace(a.Val)
+		}
+	}
+	return ""
+}
+
+// oneLine collapses all whitespace in s to single spaces.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// tidyMarkdown collapses runs of spaces outside code blocks, trims trailing spaces and
+// keeps at most one blank line between blocks.
+func tidyMarkdown(s string) string {
+	var out []string
+	fenced := false
+	blank := false
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasPrefix(line, "```") {
+			fenced = !fenced
+		}
+		if !fenced && !strings.HasPrefix(line, "```") {
+			indent := line[:len(line)-len(strings.TrimLeft(line, " "))]
+			line = indent + oneLine(line)
+			if strings.TrimSpace(line) == "" {
+				line = ""
+			}
+		}
+		if line == "" && !fenced {
+			blank = len(out) > 0
+			continue
+		}
+		if blank {
+			out = append(out, "")
+			blank = false
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,37 @@
+// File: pkg/parser/markdown_test.go
+
+package parser
+
+import "testing"
+
+// TestToMarkdown verifies the Markdown rendering of common content elements.
+func TestToMarkdown(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    string
+		expected string
+	}{
+		{"headings and paragraphs", "<h2>Title</h2>\n<p>One\n  two.</p><p>Three</p>", "## Title\n\nOne two.\n\nThree"},
+		{"inline formatting", "<p>A <b>bold</b> and <em> loose </em> <code>x := 1</code> word</p>", "A **bold** and *loose* `x := 1` word"},
+		{"links and images", `<p><a href="https://e.com/a">the link</a> <a href="#top">top</a> <img src="/i.png" alt="pic"></p>`, "[the link](https://e.com/a) top ![pic](/i.png)"},
+		{"nested lists", "<ul><li>one</li><li>two<ol><li>a</li><li>b</li></ol></li></ul>", "- one\n- two\n\n  1. a\n  2. b"},
+		{"ordered list start", `<ol start="3"><li>c</li><li>d</li></ol>`, "3. c\n4. d"},
+		{"block quote", "<blockquote><p>Quoted</p><p>Again</p></blockquote>", "> Quoted\n>\n> Again"},
+		{"code block keeps whitespace", "<pre><code>if x {\n    y()\n}\n</code></pre>", "```\nif x {\n    y()\n}\n```"},
+		{"table", "<table><tr><th>A</th><th>B</th></tr><tr><td>1</td><td>x|y</td></tr><tr><td>2</td></tr></table>", "| A | B |\n| --- | --- |\n| 1 | x\\|y |\n| 2 | |"},
+		{"escapes literal syntax", "<p># 1 *not* a_b [x] <code>a*b_c</code></p>", "\\# 1 \\*not\\* a\\_b \\[x\\] `a*b_c`"},
+		{"hidden elements and rules", "<p>a</p><script>x()</script><hr><p>b</p>", "a\n\n---\n\nb"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := toMarkdown(tc.input)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
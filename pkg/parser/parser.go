@@ -2,33 +2,172 @@
 
 package parser
 
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+)
+
+/*
+Record is the structured result of parsing a single page.
+
+Fields:
+  - URL: The address of the page the record was extracted from.
+  - Fields: Extracted values keyed by field name. Each value is one of:
+    string (single value), []string (Multiple), map[string]interface{} (nested Fields),
+    or []map[string]interface{} (nested Fields with Multiple). After a PostProcessor
+    has run, transforms may also yield float64, time.Time or []interface{} values.
+  - Structured: The JSON-LD, Microdata, RDFa, OpenGraph and Twitter Card metadata
+    embedded in the page, or nil if there is none.
+  - FetchedAt: When the page was fetched. ParseHTML leaves it zero; callers set it from
+    crawler.Page.FetchedAt so relative dates ("2 hours ago") resolve correctly.
+
+Usage:
+
+	records, err := ParseHTML(content, pageURL, cfg)
+	if err != nil {
+	    // Handle error
+	}
+	price, _ := records[0].Fields["price"].(string)
+*/
+type Record struct {
+	URL        string                 `json:"url"`
+	Fields     map[string]interface{} `json:"fields"`
+	Structured *StructuredData        `json:"structured,omitempty"`
+	FetchedAt  time.Time              `json:"fetchedAt"`
+}
+
 /*
-ParseHTML analyzes HTML content and extracts data based on configuration or rules.
+ParseHTML analyzes HTML content and extracts data based on the configured ParseRules.
 
 Parameters:
   - htmlContent: A string containing the HTML to be parsed.
+  - pageURL: The address the content was fetched from; used to resolve relative
+    href and src attribute values. May be empty.
+  - cfg: The loaded configuration; every rule returned by cfg.FieldRules() is evaluated.
 
 Returns:
-  - A map with string keys and values representing the extracted data.
-  - An error if parsing fails.
-
-Usage:
-
-	This function is currently a stub. In the future, it will be expanded to handle specific
-	selectors, attributes, and more complex parsing logic to extract meaningful data from HTML.
+  - The records extracted from the page. Without ParseRules.Items this is a single record
+    holding the page's fields; with it, one record per matched item (possibly none),
+    holding the item's fields along with the page's. Every record carries the page's
+    structured data. Fields that match nothing (directly or through a Fallback reference)
+    and have no Default are omitted.
+  - An error if the HTML cannot be parsed, a selector is invalid, or a Required field is missing.
 
 Example:
 
-	data, err := ParseHTML("<html>...</html>")
+	records, err := ParseHTML("<html>...</html>", "https://example.com/", cfg)
 	if err != nil {
 	    // Handle error
 	}
-	// Use the extracted data from 'data'
+	title, _ := records[0].Fields["title"].(string)
 
 Notes:
-  - For now, the function returns an empty map and a nil error.
+  - Without Multiple, only the first element matched by a selector is used.
+  - Element values hold the element's trimmed text, or its inner HTML where markup is
+    needed (see keepsMarkup); run the records through a PostProcessor to apply
+    DataFormatting and per-field Transforms.
+  - Matches that yield an empty value (or lack the requested attribute) are treated as missing.
+  - An item field takes precedence over a page field of the same name. Items whose
+    fields all match nothing are skipped, as are items missing a Required field; the
+    same holds for each object of a Multiple rule with nested Fields. A Required page
+    field, or an Items rule that is itself Required, still fails the page.
+  - A <base href> element in the document takes precedence over pageURL.
 */
-func ParseHTML(htmlContent string) (map[string]string, error) {
-	// Stub: for now, just return an empty map
-	return map[string]string{}, nil
+func ParseHTML(htmlContent, pageURL string, cfg *config.Config) ([]*Record, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %v", err)
+	}
+
+	ex := &extractor{cfg: cfg, base: documentBase(doc, pageURL), structured: ExtractStructuredData(doc), doc: doc}
+	fields, err := ex.extractFields(doc.Selection, cfg.FieldRules(), false)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ParseRules.Items == nil {
+		return []*Record{{URL: pageURL, Fields: fields, Structured: ex.structured}}, nil
+	}
+
+	rule := *cfg.ParseRules.Items
+	rule.Multiple = true
+	value, err := ex.extractField(doc.Selection, "items", rule, false)
+	if err != nil {
+		return nil, err
+	}
+	items, _ := value.([]map[string]interface{})
+	records := make([]*Record, 0, len(items))
+	for _, item := range items {
+		merged := make(map[string]interface{}, len(fields)+len(item))
+		for name, v := range fields {
+			merged[name] = cloneValue(v)
+		}
+		for name, v := range item {
+			merged[name] = v
+		}
+		records = append(records, &Record{URL: pageURL, Fields: merged, Structured: ex.structured})
+	}
+	return records, nil
+}
+
+// cloneValue deep-copies an extracted value so each item record can be post-processed
+// independently.
+func cloneValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []string:
+		return append([]string(nil), v...)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			out[k] = cloneValue(e)
+		}
+		return out
+	case []map[string]interface{}:
+		out := make([]map[string]interface{}, len(v))
+		for i, m := range v {
+			out[i] = cloneValue(m).(map[string]interface{})
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = cloneValue(e)
+		}
+		return out
+	}
+	return v
+}
+
+// documentBase returns the URL relative references in doc resolve against, or nil if unknown.
+func documentBase(doc *goquery.Document, pageURL string) *url.URL {
+	base, err := url.Parse(pageURL)
+	if err != nil || !base.IsAbs() {
+		base = nil
+	}
+	if href, ok := doc.Find("base[href]").First().Attr("href"); ok {
+		if ref, err := url.Parse(strings.TrimSpace(href)); err == nil {
+			if base != nil {
+				return base.ResolveReference(ref)
+			}
+			if ref.IsAbs() {
+				return ref
+			}
+		}
+	}
+	return base
+}
+
+// sortedNames returns the keys of rules in a stable order so that errors are deterministic.
+func sortedNames(rules map[string]config.FieldRule) []string {
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
@@ -2,27 +2,468 @@
 
 package parser
 
-import "testing"
+import (
+	"reflect"
+	"strings"
+	"testing"
 
-// TestParseHTML verifies that ParseHTML returns an empty map and no error
-// regardless of the input provided.
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+)
+
+const testPage = `<html>
+<head>
+	<title> Example Article </title>
+	<meta name="description" content="A short summary.">
+	<meta property="article:published_time" content="2024-03-01T10:00:00Z">
+</head>
+<body>
+	<article>
+		<h1>Heading</h1>
+		<p>First paragraph.</p>
+	</article>
+	<span class="author-name">Jane Doe</span>
+	<span class="author-name">Second Author</span>
+	<ul class="tags"><li>go</li><li> </li><li>scraping</li></ul>
+	<a class="buy" href="/cart?id=7">Buy</a>
+	<div class="review" data-rating="5"><p>Great.</p><time datetime="2024-03-02">Mar 2</time></div>
+	<div class="review" data-rating="2"><p>Meh.</p></div>
+	<img class="gallery" src="img/1.png"><img class="gallery" src="https://cdn.example.com/2.png">
+</body>
+</html>`
+
+// newTestConfig returns a config with the default ParseRules from configs/default.json.
+func newTestConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.ParseRules.Title = "title"
+	cfg.ParseRules.MetaDescription = "meta[name='description']"
+	cfg.ParseRules.ArticleContent = "article"
+	cfg.ParseRules.Author = ".author-name"
+	cfg.ParseRules.DatePublished = "meta[property='article:published_time']"
+	return cfg
+}
+
+// TestParseHTML verifies selector-based extraction for the shorthand rules and ParseRules.Fields.
 func TestParseHTML(t *testing.T) {
-	// Test with a non-empty HTML string.
-	html := "<html><body><p>Hello, World!</p></body></html>"
-	data, err := ParseHTML(html)
+	cases := []struct {
+		desc      string
+		html      string
+		pageURL   string
+		setup     func(cfg *config.Config)
+		expected  map[string]interface{}
+		expectErr bool
+	}{
+		{
+			desc: "Default rules extract every field",
+			html: testPage,
+			expected: map[string]interface{}{
+				"title":           "Example Article",
+				"metaDescription": "A short summary.",
+				"articleContent":  "<h1>Heading</h1>\n\t\t<p>First paragraph.</p>",
+				"author":          "Jane Doe",
+				"datePublished":   "2024-03-01T10:00:00Z",
+			},
+		},
+		{
+			desc: "Unmatched and empty rules are omitted",
+			html: testPage,
+			setup: func(cfg *config.Config) {
+				cfg.ParseRules.Author = ".missing"
+				cfg.ParseRules.ArticleContent = ""
+			},
+			expected: map[string]interface{}{
+				"title":           "Example Article",
+				"metaDescription": "A short summary.",
+				"datePublished":   "2024-03-01T10:00:00Z",
+			},
+		},
+		{
+			desc: "Meta without content attribute is omitted",
+			html: `<meta name="description">`,
+			setup: func(cfg *config.Config) {
+				cfg.ParseRules.Title = ""
+			},
+			expected: map[string]interface{}{},
+		},
+		{
+			desc:     "Empty input produces an empty map",
+			html:     "",
+			expected: map[string]interface{}{},
+		},
+		{
+			desc: "Fields support attributes, lists and defaults",
+			html: testPage,
+			setup: func(cfg *config.Config) {
+				cfg.ParseRules = struct {
+					Title           string                      `json:"title,omitempty"`
+					MetaDescription string                      `json:"metaDescription,omitempty"`
+					ArticleContent  string                      `json:"articleContent,omitempty"`
+					Author          string                      `json:"author,omitempty"`
+					DatePublished   string                      `json:"datePublished,omitempty"`
+					Fields          map[string]config.FieldRule `json:"fields,omitempty"`
+					Items           *config.FieldRule           `json:"items,omitempty"`
+				}{
+					Fields: map[string]config.FieldRule{
+						"buyLink": {Selector: "a.buy", Attribute: "href"},
+						"tags":    {Selector: ".tags li", Multiple: true},
+						"authors": {Selector: ".author-name", Multiple: true},
+						"price":   {Selector: ".price", Default: "unknown"},
+						"sku":     {Selector: ".sku"},
+					},
+				}
+			},
+			expected: map[string]interface{}{
+				"buyLink": "/cart?id=7",
+				"tags":    []string{"go", "scraping"},
+				"authors": []string{"Jane Doe", "Second Author"},
+				"price":   "unknown",
+			},
+		},
+		{
+			desc:    "Attribute shorthand and URL resolution",
+			html:    testPage,
+			pageURL: "https://example.com/shop/item",
+			setup: func(cfg *config.Config) {
+				*cfg = config.Config{}
+				cfg.ParseRules.Fields = map[string]config.FieldRule{
+					"buyLink": {Selector: "a.buy@href"},
+					"images":  {Selector: "img.gallery", Attribute: "src", Multiple: true},
+					"ratings": {Selector: ".review @data-rating", Multiple: true},
+					"posted":  {Selector: "time@datetime"},
+				}
+			},
+			expected: map[string]interface{}{
+				"buyLink": "https://example.com/cart?id=7",
+				"images":  []string{"https://example.com/shop/img/1.png", "https://cdn.example.com/2.png"},
+				"ratings": []string{"5", "2"},
+				"posted":  "2024-03-02",
+			},
+		},
+		{
+			desc:    "Base element overrides the page URL",
+			html:    `<head><base href="https://mirror.example.org/a/"></head><a href="b">B</a>`,
+			pageURL: "https://example.com/",
+			setup: func(cfg *config.Config) {
+				*cfg = config.Config{}
+				cfg.ParseRules.Fields = map[string]config.FieldRule{"link": {Selector: "a@href"}}
+			},
+			expected: map[string]interface{}{"link": "https://mirror.example.org/a/b"},
+		},
+		{
+			desc: "Nested fields produce objects",
+			html: testPage,
+			setup: func(cfg *config.Config) {
+				*cfg = config.Config{}
+				cfg.ParseRules.Fields = map[string]config.FieldRule{
+					"reviews": {
+						Selector: ".review",
+						Multiple: true,
+						Fields: map[string]config.FieldRule{
+							"rating": {Selector: "@data-rating"},
+							"body":   {Selector: "p"},
+							"date":   {Selector: "time", Attribute: "datetime"},
+						},
+					},
+					"firstReview": {
+						Selector: ".review",
+						Fields:   map[string]config.FieldRule{"body": {Selector: "p"}},
+					},
+				}
+			},
+			expected: map[string]interface{}{
+				"reviews": []map[string]interface{}{
+					{"rating": "5", "body": "Great.", "date": "2024-03-02"},
+					{"rating": "2", "body": "Meh."},
+				},
+				"firstReview": map[string]interface{}{"body": "Great."},
+			},
+		},
+		{
+			desc: "Missing required nested field skips that object",
+			html: testPage,
+			setup: func(cfg *config.Config) {
+				*cfg = config.Config{}
+				cfg.ParseRules.Fields = map[string]config.FieldRule{
+					"reviews": {
+						Selector: ".review",
+						Multiple: true,
+						Fields:   map[string]config.FieldRule{"date": {Selector: "time", Required: true}},
+					},
+				}
+			},
+			expected: map[string]interface{}{
+				"reviews": []map[string]interface{}{{"date": "Mar 2"}},
+			},
+		},
+		{
+			desc: "Missing required field of a single nested object is an error",
+			html: testPage,
+			setup: func(cfg *config.Config) {
+				cfg.ParseRules.Fields = map[string]config.FieldRule{
+					"firstReview": {
+						Selector: ".review",
+						Fields:   map[string]config.FieldRule{"author": {Selector: ".author", Required: true}},
+					},
+				}
+			},
+			expectErr: true,
+		},
+		{
+			desc:    "XPath rules select elements, attributes, text and scalars",
+			html:    testPage,
+			pageURL: "https://example.com/shop/item",
+			setup: func(cfg *config.Config) {
+				*cfg = config.Config{}
+				cfg.ParseRules.Fields = map[string]config.FieldRule{
+					"heading":   {Selector: "//article/h1", Type: config.SelectorXPath},
+					"buyLink":   {Selector: "//a[text()='Buy']/@href", Type: config.SelectorXPath},
+					"afterGo":   {Selector: "//li[.='go']/following-sibling::li[normalize-space()]/text()", Type: config.SelectorXPath},
+					"reviews":   {Selector: "count(//div[@class='review'])", Type: config.SelectorXPath},
+					"hasAuthor": {Selector: "boolean(//span[@class='author-name'])", Type: config.SelectorXPath},
+					"ratings": {
+						Selector: "//div[@class='review']",
+						Type:     config.SelectorXPath,
+						Multiple: true,
+						Fields: map[string]config.FieldRule{
+							"rating": {Selector: "@data-rating", Type: config.SelectorXPath},
+							"body":   {Selector: "p"},
+						},
+					},
+				}
+			},
+			expected: map[string]interface{}{
+				"heading":   "Heading",
+				"buyLink":   "https://example.com/cart?id=7",
+				"afterGo":   "scraping",
+				"reviews":   "2",
+				"hasAuthor": "true",
+				"ratings": []map[string]interface{}{
+					{"rating": "5", "body": "Great."},
+					{"rating": "2", "body": "Meh."},
+				},
+			},
+		},
+		{
+			desc: "Nested fields on an XPath value are an error",
+			html: testPage,
+			setup: func(cfg *config.Config) {
+				cfg.ParseRules.Fields = map[string]config.FieldRule{
+					"link": {
+						Selector: "//a/@href",
+						Type:     config.SelectorXPath,
+						Fields:   map[string]config.FieldRule{"x": {Selector: "span"}},
+					},
+				}
+			},
+			expectErr: true,
+		},
+		{
+			desc: "Invalid XPath expression is an error",
+			html: testPage,
+			setup: func(cfg *config.Config) {
+				cfg.ParseRules.Fields = map[string]config.FieldRule{
+					"x": {Selector: "//div[", Type: config.SelectorXPath},
+				}
+			},
+			expectErr: true,
+		},
+		{
+			desc: "Unknown selector type is an error",
+			html: testPage,
+			setup: func(cfg *config.Config) {
+				cfg.ParseRules.Fields = map[string]config.FieldRule{
+					"x": {Selector: "div", Type: "regex"},
+				}
+			},
+			expectErr: true,
+		},
+		{
+			desc: "Fallback references fill fields the selectors miss",
+			html: `<head>
+				<meta property="og:title" content="OG Title">
+				<script type="application/ld+json">{"@type": "Article", "author": {"@type": "Person", "name": "Ada"}, "keywords": ["a", "b"]}</script>
+			</head>`,
+			setup: func(cfg *config.Config) {
+				cfg.ParseRules.Fields = map[string]config.FieldRule{
+					"keywords": {Fallback: []string{"jsonld:Article.keywords"}, Multiple: true},
+					"sku":      {Selector: ".sku", Fallback: []string{"jsonld:Product.sku", "og:sku"}, Default: "none"},
+				}
+			},
+			expected: map[string]interface{}{
+				"title":    "OG Title",
+				"author":   "Ada",
+				"keywords": []string{"a", "b"},
+				"sku":      "none",
+			},
+		},
+		{
+			desc: "Selector matches take precedence over fallbacks",
+			html: `<head><title>Page Title</title><meta property="og:title" content="OG Title"></head>`,
+			setup: func(cfg *config.Config) {
+				cfg.ParseRules.MetaDescription = ""
+				cfg.ParseRules.DatePublished = ""
+			},
+			expected: map[string]interface{}{"title": "Page Title"},
+		},
+		{
+			desc: "Fields override shorthand keys of the same name",
+			html: testPage,
+			setup: func(cfg *config.Config) {
+				cfg.ParseRules.Fields = map[string]config.FieldRule{
+					"title": {Selector: "h1"},
+				}
+				cfg.ParseRules.MetaDescription = ""
+				cfg.ParseRules.ArticleContent = ""
+				cfg.ParseRules.Author = ""
+				cfg.ParseRules.DatePublished = ""
+			},
+			expected: map[string]interface{}{"title": "Heading"},
+		},
+		{
+			desc: "Missing required field is an error",
+			html: testPage,
+			setup: func(cfg *config.Config) {
+				cfg.ParseRules.Fields = map[string]config.FieldRule{
+					"price": {Selector: ".price", Required: true},
+				}
+			},
+			expectErr: true,
+		},
+		{
+			desc: "Field without selector is an error",
+			html: testPage,
+			setup: func(cfg *config.Config) {
+				cfg.ParseRules.Fields = map[string]config.FieldRule{"price": {}}
+			},
+			expectErr: true,
+		},
+		{
+			desc: "Invalid selector is an error",
+			html: testPage,
+			setup: func(cfg *config.Config) {
+				cfg.ParseRules.Title = "div[unclosed"
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cfg := newTestConfig()
+			if tc.setup != nil {
+				tc.setup(cfg)
+			}
+			records, err := ParseHTML(tc.html, tc.pageURL, cfg)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("Expected error, got %v", records)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(records) != 1 {
+				t.Fatalf("Expected 1 record, got %d", len(records))
+			}
+			record := records[0]
+			if record.URL != tc.pageURL {
+				t.Errorf("Expected URL %q, got %q", tc.pageURL, record.URL)
+			}
+			if !reflect.DeepEqual(record.Fields, tc.expected) {
+				t.Errorf("Expected %#v, got %#v", tc.expected, record.Fields)
+			}
+		})
+	}
+}
+
+// listingPage is a product listing with a page-level heading and three product cards.
+const listingPage = `<html><body>
+<h1>Laptops</h1>
+<ul class="tags"><li>sale</li></ul>
+<div class="card"><h2>Alpha</h2><span class="price">$1,000</span><a href="/p/alpha">View</a></div>
+<div class="card"><h2>Beta</h2><a href="/p/beta">View</a></div>
+<div class="card"><span class="empty"></span></div>
+</body></html>`
+
+// TestParseHTMLItems verifies that an Items rule yields one record per matched item,
+// combining each item's fields with the page's.
+func TestParseHTMLItems(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ParseRules.Fields = map[string]config.FieldRule{
+		"category": {Selector: "h1"},
+		"tags":     {Selector: ".tags li", Multiple: true, Transforms: []config.TransformSpec{{Name: "upper"}}},
+	}
+	cfg.ParseRules.Items = &config.FieldRule{
+		Selector: ".card",
+		Fields: map[string]config.FieldRule{
+			"name":  {Selector: "h2"},
+			"price": {Selector: ".price", Transforms: []config.TransformSpec{{Name: "toNumber"}}},
+			"link":  {Selector: "a@href"},
+		},
+	}
+	records, err := ParseHTML(listingPage, "https://example.com/laptops", cfg)
 	if err != nil {
-		t.Errorf("Expected no error for non-empty input, got %v", err)
+		t.Fatalf("Unexpected error: %v", err)
 	}
-	if len(data) != 0 {
-		t.Errorf("Expected empty map for non-empty input, got %v", data)
+	pp, err := NewPostProcessor(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
 	}
+	for _, record := range records {
+		if err := pp.Process(record); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	expected := []map[string]interface{}{
+		{"category": "Laptops", "tags": []string{"SALE"}, "name": "Alpha", "price": 1000.0, "link": "https://example.com/p/alpha"},
+		{"category": "Laptops", "tags": []string{"SALE"}, "name": "Beta", "link": "https://example.com/p/beta"},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("Expected %d records, got %d", len(expected), len(records))
+	}
+	for i, record := range records {
+		if record.URL != "https://example.com/laptops" {
+			t.Errorf("Expected URL on record %d, got %q", i, record.URL)
+		}
+		if !reflect.DeepEqual(record.Fields, expected[i]) {
+			t.Errorf("Record %d: expected %#v, got %#v", i, expected[i], record.Fields)
+		}
+	}
+}
 
-	// Test with an empty string.
-	data, err = ParseHTML("")
+// TestParseHTMLItemsRequired verifies that an item missing a Required field is skipped
+// without failing the page.
+func TestParseHTMLItemsRequired(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ParseRules.Items = &config.FieldRule{
+		Selector: ".card",
+		Fields: map[string]config.FieldRule{
+			"name":  {Selector: "h2"},
+			"price": {Selector: ".price", Required: true},
+		},
+	}
+	records, err := ParseHTML(listingPage, "", cfg)
 	if err != nil {
-		t.Errorf("Expected no error for empty input, got %v", err)
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(records) != 1 || records[0].Fields["name"] != "Alpha" {
+		t.Fatalf("Expected only the Alpha record, got %v", records)
+	}
+}
+
+// TestParseHTMLItemsNone verifies that a page without items yields no records, or an error
+// when the Items rule is Required.
+func TestParseHTMLItemsNone(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ParseRules.Items = &config.FieldRule{Selector: ".missing", Fields: map[string]config.FieldRule{"name": {Selector: "h2"}}}
+	records, err := ParseHTML(listingPage, "", cfg)
+	if err != nil || len(records) != 0 {
+		t.Errorf("Expected no records, got %v (err %v)", records, err)
 	}
-	if len(data) != 0 {
-		t.Errorf("Expected empty map for empty input, got %v", data)
+
+	cfg.ParseRules.Items.Required = true
+	if _, err := ParseHTML(listingPage, "", cfg); err == nil || !strings.Contains(err.Error(), "required field items") {
+		t.Errorf("Expected required field error, got %v", err)
 	}
 }
@@ -0,0 +1,501 @@
+// File: pkg/parser/postprocess.go
+
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/text/unicode/norm"
+)
+
+/*
+PostProcessor cleans and types the values of parsed records before they are stored.
+
+Every field runs a default chain derived from DataFormatting, followed by the
+Transforms declared on its rule (see transforms.go), which may turn text into
+float64 ("toNumber") or time.Time ("toDate") values:
+  - RemoveHTML: "stripHTML" for fields read from element content (not attributes), unless
+    the rule's own Transforms already convert the markup ("stripHTML" or "toMarkdown").
+  - Fields read as element text, or stripped of their markup: "decodeEntities", so text
+    that was escaped twice in the page ("Tom &amp;amp; Jerry") still reads "Tom & Jerry".
+  - CleanWhitespace: "collapseWhitespace".
+  - Always: "normalizeUnicode".
+
+Usage:
+
+	pp, err := NewPostProcessor(cfg)
+	if err != nil {
+	    // Handle error (e.g. an unknown transform name)
+	}
+	records, _ := ParseHTML(content, pageURL, cfg)
+	for _, record := range records {
+	    if err := pp.Process(record); err != nil {
+	        // Handle error
+	    }
+	}
+*/
+type PostProcessor struct {
+	fields map[string]chain
+}
+
+/*
+chain is the compiled transform sequence for one field.
+
+Fields:
+  - steps: The transforms applied to the field's value, in order.
+  - fields: The chains of nested rules, keyed by nested field name.
+*/
+type chain struct {
+	steps  []step
+	fields map[string]chain
+}
+
+/*
+NewPostProcessor compiles the transform chains for every rule in cfg, including the
+nested Fields of ParseRules.Items, which take precedence over page rules of the same name.
+
+Returns:
+  - A PostProcessor ready to process records parsed with cfg.
+  - An error if a rule names a transform that is not registered or whose parameters
+    are invalid (e.g. a regex pattern that does not compile).
+*/
+func NewPostProcessor(cfg *config.Config) (*PostProcessor, error) {
+	rules := cfg.FieldRules()
+	if items := cfg.ParseRules.Items; items != nil {
+		for name, rule := range items.Fields {
+			rules[name] = rule
+		}
+	}
+	fields, err := compileChains(cfg, rules, "")
+	if err != nil {
+		return nil, err
+	}
+	return &PostProcessor{fields: fields}, nil
+}
+
+// compileChains builds a chain per rule; prefix qualifies nested field names in errors.
+func compileChains(cfg *config.Config, rules map[string]config.FieldRule, prefix string) (map[string]chain, error) {
+	chains := make(map[string]chain, len(rules))
+	for _, name := range sortedNames(rules) {
+		rule := rules[name]
+		_, attribute := rule.Target()
+
+		var specs []config.TransformSpec
+		if attribute == "" {
+			markup := keepsMarkup(cfg, name, rule, prefix != "")
+			if markup && cfg.DataFormatting.RemoveHTML && !convertsMarkup(rule.Transforms) {
+				specs = append(specs, config.TransformSpec{Name: "stripHTML"})
+			}
+			if !markup || cfg.DataFormatting.RemoveHTML && !convertsMarkup(rule.Transforms) {
+				specs = append(specs, config.TransformSpec{Name: "decodeEntities"})
+			}
+		}
+		// stripHTML and toMarkdown tidy whitespace themselves after converting the
+		// markup, so collapsing it beforehand would only lose <pre> indentation.
+		converted := attribute == "" && (convertsMarkup(rule.Transforms) || cfg.DataFormatting.RemoveHTML)
+		if cfg.DataFormatting.CleanWhitespace && !converted {
+			specs = append(specs, config.TransformSpec{Name: "collapseWhitespace"})
+		}
+		specs = append(specs, config.TransformSpec{Name: "normalizeUnicode"})
+		specs = append(specs, rule.Transforms...)
+
+		c := chain{steps: make([]step, 0, len(specs))}
+		for _, spec := range specs {
+			s, err := compileStep(spec, cfg)
+			if err != nil {
+				return nil, fmt.Errorf("field %s%s: %v", prefix, name, err)
+			}
+			c.steps = append(c.steps, s)
+		}
+		nested, err := compileChains(cfg, rule.Fields, prefix+name+".")
+		if err != nil {
+			return nil, err
+		}
+		c.fields = nested
+		chains[name] = c
+	}
+	return chains, nil
+}
+
+/*
+keepsMarkup reports whether ParseHTML keeps the inner HTML of elements matched by a rule,
+rather than their text.
+
+Parameters:
+  - cfg: The loaded configuration.
+  - name: The field name.
+  - rule: The field's rule.
+  - nested: Whether the rule is nested under another rule.
+
+Returns:
+  - true when the markup is needed: with DataFormatting.RemoveHTML (stripHTML keeps
+    paragraph breaks that plain text loses), when the rule's Transforms convert markup
+    ("stripHTML" or "toMarkdown"), and for "articleContent" in the "html" content format.
+*/
+func keepsMarkup(cfg *config.Config, name string, rule config.FieldRule, nested bool) bool {
+	if cfg.DataFormatting.RemoveHTML || convertsMarkup(rule.Transforms) {
+		return true
+	}
+	format := cfg.DataFormatting.ContentFormat
+	return !nested && name == "articleContent" && (format == "" || format == config.ContentHTML)
+}
+
+// convertsMarkup reports whether specs include a transform that consumes HTML markup.
+func convertsMarkup(specs []config.TransformSpec) bool {
+	for _, spec := range specs {
+		if spec.Name == "stripHTML" || spec.Name == "toMarkdown" {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Process applies each field's chain to record in place.
+
+Returns:
+  - nil, or an error listing the fields whose chain failed (e.g. "toNumber" on "Call
+    us"). Such a field keeps the value it had before the failing step; every other field
+    is processed as usual, so the error is a warning rather than a reason to drop the
+    record.
+
+Notes:
+  - Values that become empty are removed, skipping the rest of the chain: single values
+    are deleted from the record, and dropped from lists.
+  - Fields that are missing but whose chain has a "default" step are added with the
+    default value.
+  - Fields without a configured rule are left untouched.
+  - Relative dates are resolved against record.FetchedAt, or the current time if unset.
+*/
+func (pp *PostProcessor) Process(record *Record) error {
+	if record.Fields == nil {
+		record.Fields = make(map[string]interface{})
+	}
+	return processFields(record.Fields, pp.fields, runContext{fetchedAt: record.FetchedAt})
+}
+
+// processFields applies chains to the matching entries of fields, seeding missing fields
+// that have a default, and returns the failures of every field joined together.
+func processFields(fields map[string]interface{}, chains map[string]chain, ctx runContext) error {
+	names := make([]string, 0, len(chains))
+	for name := range chains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		c := chains[name]
+		value, ok := fields[name]
+		if !ok && !c.hasDefault() {
+			continue
+		}
+		processed, err := c.apply(value, ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("field %s: %v", name, err))
+		}
+		if processed == nil {
+			delete(fields, name)
+		} else {
+			fields[name] = processed
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// hasDefault reports whether the chain fills in empty values.
+func (c chain) hasDefault() bool {
+	for _, s := range c.steps {
+		if s.keepEmpty {
+			return true
+		}
+	}
+	return false
+}
+
+// apply runs the chain over a single field value of any shape produced by ParseHTML.
+// On error it returns the value as far as it was processed.
+func (c chain) apply(value interface{}, ctx runContext) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, string:
+		return c.run(v, ctx)
+	case []string:
+		values := make([]interface{}, len(v))
+		for i, s := range v {
+			values[i] = s
+		}
+		return c.run(values, ctx)
+	case map[string]interface{}:
+		err := processFields(v, c.fields, ctx)
+		if len(v) == 0 {
+			return nil, err
+		}
+		return v, err
+	case []map[string]interface{}:
+		var errs []error
+		out := make([]map[string]interface{}, 0, len(v))
+		for _, m := range v {
+			if err := processFields(m, c.fields, ctx); err != nil {
+				errs = append(errs, err)
+			}
+			if len(m) > 0 {
+				out = append(out, m)
+			}
+		}
+		if len(out) == 0 {
+			return nil, errors.Join(errs...)
+		}
+		return out, errors.Join(errs...)
+	}
+	return value, nil
+}
+
+// run applies the steps to a scalar or []interface{} value. Scalar steps are mapped over
+// lists, empty elements are dropped, and empty values skip every step but "default".
+// A failing step ends the chain, returning the value from before that step.
+func (c chain) run(value interface{}, ctx runContext) (interface{}, error) {
+	for _, s := range c.steps {
+		next, err := s.apply(value, ctx)
+		if err != nil {
+			return result(value), err
+		}
+		value = next
+	}
+	return result(value), nil
+}
+
+// apply runs one step over value.
+func (s step) apply(value interface{}, ctx runContext) (interface{}, error) {
+	if isEmpty(value) {
+		if s.keepEmpty {
+			return s.scalar(value, ctx)
+		}
+		return value, nil
+	}
+	values, isList := value.([]interface{})
+	switch {
+	case s.list != nil:
+		if !isList {
+			values = []interface{}{value}
+		}
+		return s.list(values)
+	case isList:
+		out := make([]interface{}, 0, len(values))
+		for _, v := range values {
+			r, err := s.scalar(v, ctx)
+			if err != nil {
+				return nil, err
+			}
+			out = appendValues(out, r)
+		}
+		return out, nil
+	default:
+		value, err := s.scalar(value, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if parts, ok := value.([]interface{}); ok {
+			value = appendValues(nil, parts)
+		}
+		return value, nil
+	}
+}
+
+// result normalizes a chain's final value: empty values become nil and lists are collected.
+func result(value interface{}) interface{} {
+	if isEmpty(value) {
+		return nil
+	}
+	if values, ok := value.([]interface{}); ok {
+		return collect(values)
+	}
+	return value
+}
+
+// appendValues appends v, or each element of v if it is a list, skipping empty values.
+func appendValues(out []interface{}, v interface{}) []interface{} {
+	if parts, ok := v.([]interface{}); ok {
+		for _, p := range parts {
+			out = appendValues(out, p)
+		}
+		return out
+	}
+	if isEmpty(v) {
+		return out
+	}
+	return append(out, v)
+}
+
+// blockElements start a new paragraph when rendered as text.
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"div": true, "dl": true, "fieldset": true, "figcaption": true,
+	"figure": true, "footer": true, "form": true, "h1": true, "h2": true, "h3": true,
+	"h4": true, "h5": true, "h6": true, "header": true, "hr": true,
+	"main": true, "nav": true, "ol": true, "p": true, "pre": true, "section": true,
+	"table": true, "ul": true,
+}
+
+// lineElements start a new line when rendered as text; their end is left to the next
+// line or enclosing block so consecutive items are not separated by blank lines.
+var lineElements = map[string]bool{
+	"li": true, "dt": true, "dd": true, "tr": true, "caption": true,
+}
+
+// hiddenElements contribute no text.
+var hiddenElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "head": true,
+}
+
+// stripHTML removes tags and decodes entities, turning block elements into paragraph
+// breaks and <br> into line breaks. Other whitespace in the markup collapses to single
+// spaces, except inside <pre>, whose line breaks and indentation are kept.
+func stripHTML(value string) (string, error) {
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(value), context)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse HTML: %v", err)
+	}
+	return visibleText(nodes...), nil
+}
+
+// preSpace and preTab stand in for the spaces and tabs of <pre> text while the text
+// around it is collapsed. They are private-use runes, which page text does not contain.
+const (
+	preSpace = '\uE000'
+	preTab   = '\uE001'
+)
+
+// visibleText renders the text of nodes with whitespace collapsed outside <pre>.
+func visibleText(nodes ...*html.Node) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		renderText(&b, n, false)
+	}
+	text, _ := collapseWhitespace(b.String())
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case preSpace:
+			return ' '
+		case preTab:
+			return '\t'
+		}
+		return r
+	}, text)
+}
+
+// renderText writes the visible text of n to b; pre reports whether n is inside <pre>.
+func renderText(b *strings.Builder, n *html.Node, pre bool) {
+	switch n.Type {
+	case html.TextNode:
+		if pre {
+			b.WriteString(strings.Map(func(r rune) rune {
+				switch r {
+				case ' ':
+					return preSpace
+				case '\t':
+					return preTab
+				}
+				return r
+			}, n.Data))
+		} else {
+			b.WriteString(strings.Map(func(r rune) rune {
+				if unicode.IsSpace(r) {
+					return ' '
+				}
+				return r
+			}, n.Data))
+		}
+		return
+	case html.ElementNode:
+		if hiddenElements[n.Data] {
+			return
+		}
+		if n.Data == "br" {
+			b.WriteString("\n")
+			return
+		}
+	default:
+		return
+	}
+
+	switch {
+	case blockElements[n.Data]:
+		b.WriteString("\n\n")
+		defer b.WriteString("\n\n")
+	case lineElements[n.Data]:
+		b.WriteString("\n")
+	case n.Data == "td" || n.Data == "th":
+		b.WriteString(" ")
+		defer b.WriteString(" ")
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		renderText(b, c, pre || n.Data == "pre")
+	}
+}
+
+// collapseWhitespace reduces runs of spaces and tabs to one space and runs of blank lines
+// to a single paragraph break. Lines inside ``` fenced code blocks, as written by
+// toMarkdown, keep their indentation.
+func collapseWhitespace(value string) (string, error) {
+	lines := strings.Split(strings.ReplaceAll(value, "\r\n", "\n"), "\n")
+	fenced := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			fenced = !fenced
+			lines[i] = trimmed
+			continue
+		}
+		if fenced {
+			lines[i] = strings.TrimRight(line, " \t")
+			continue
+		}
+		lines[i] = strings.Join(strings.FieldsFunc(line, isInlineSpace), " ")
+	}
+	return joinParagraphs(lines), nil
+}
+
+// decodeEntities replaces HTML character references with the characters they name.
+func decodeEntities(value string) (string, error) {
+	return html.UnescapeString(value), nil
+}
+
+// normalizeUnicode converts value to Unicode Normalization Form C.
+func normalizeUnicode(value string) (string, error) {
+	return norm.NFC.String(value), nil
+}
+
+// isInlineSpace reports whether r is whitespace other than a line break.
+func isInlineSpace(r rune) bool {
+	return r != '\n' && unicode.IsSpace(r)
+}
+
+// joinParagraphs joins trimmed lines, keeping at most one blank line between paragraphs.
+func joinParagraphs(lines []string) string {
+	var b strings.Builder
+	blank := 0
+	for _, line := range lines {
+		if line == "" {
+			blank++
+			continue
+		}
+		if b.Len() > 0 {
+			if blank > 0 {
+				b.WriteString("\n\n")
+			} else {
+				b.WriteString("\n")
+			}
+		}
+		b.WriteString(line)
+		blank = 0
+	}
+	return b.String()
+}
@@ -0,0 +1,271 @@
+// File: pkg/parser/postprocess_test.go
+
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+)
+
+// TestBuiltinTransforms verifies the text cleanup performed by each built-in transform.
+func TestBuiltinTransforms(t *testing.T) {
+	cases := []struct {
+		desc     string
+		fn       Transform
+		input    string
+		expected string
+	}{
+		{"stripHTML keeps paragraph breaks", stripHTML, "<h1>Title</h1>\n\t<p>First\n   line &amp; more.</p><p>Second<br>line</p>", "Title\n\nFirst line & more.\n\nSecond\nline"},
+		{"stripHTML renders lists line by line", stripHTML, "<ul><li>one</li><li><b>two</b></li></ul>", "one\ntwo"},
+		{"stripHTML drops scripts and comments", stripHTML, "a<script>x()</script><!-- c --><style>p{}</style> b", "a b"},
+		{"stripHTML keeps line breaks in pre", stripHTML, "<pre>x := 1\ny := 2</pre>", "x := 1\ny := 2"},
+		{"stripHTML keeps indentation in pre", stripHTML, "<p>a  b</p><pre>if x {\n    y()\n}</pre>", "a b\n\nif x {\n    y()\n}"},
+		{"stripHTML separates table cells", stripHTML, "<table><tr><th>A</th><th>B</th></tr><tr><td>1</td><td>2</td></tr></table>", "A B\n1 2"},
+		{"collapseWhitespace", collapseWhitespace, "  a \t b c\r\n\n\n\nd  \ne ", "a b c\n\nd\ne"},
+		{"collapseWhitespace leaves code fences", collapseWhitespace, "a  b\n```\nif x {\n    y()  \n}\n```\n c  d", "a b\n```\nif x {\n    y()\n}\n```\nc d"},
+		{"decodeEntities", decodeEntities, "Tom &amp; Jerry &#8217;s &lt;b&gt;", "Tom & Jerry ’s <b>"},
+		{"normalizeUnicode composes characters", normalizeUnicode, "Café", "Café"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := tc.fn(tc.input)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestPostProcessor verifies that DataFormatting defaults and per-field transforms are applied.
+func TestPostProcessor(t *testing.T) {
+	RegisterTransform("testShout", func(v string) (string, error) { return strings.ToUpper(v) + "!", nil })
+	RegisterTransform("testFail", func(v string) (string, error) { return "", errors.New("boom") })
+
+	cases := []struct {
+		desc      string
+		setup     func(cfg *config.Config)
+		fields    map[string]interface{}
+		expected  map[string]interface{}
+		expectErr bool
+	}{
+		{
+			desc: "RemoveHTML and CleanWhitespace",
+			setup: func(cfg *config.Config) {
+				cfg.DataFormatting.RemoveHTML = true
+				cfg.DataFormatting.CleanWhitespace = true
+				cfg.ParseRules.Fields = map[string]config.FieldRule{
+					"body": {Selector: "article"},
+					"link": {Selector: "a@href"},
+					"tags": {Selector: "li", Multiple: true},
+				}
+			},
+			fields: map[string]interface{}{
+				"body": "<p>One  &amp;\n two</p><p>Three</p>",
+				"link": "https://example.com/?a=1&copy=2",
+				"tags": []string{"<b>go</b>", "<i></i>", " web "},
+			},
+			expected: map[string]interface{}{
+				"body": "One & two\n\nThree",
+				"link": "https://example.com/?a=1&copy=2",
+				"tags": []string{"go", "web"},
+			},
+		},
+		{
+			desc: "Markup is kept without RemoveHTML",
+			setup: func(cfg *config.Config) {
+				cfg.ParseRules.Fields = map[string]config.FieldRule{"body": {Selector: "article"}}
+			},
+			fields:   map[string]interface{}{"body": "<p>One  two</p>"},
+			expected: map[string]interface{}{"body": "<p>One  two</p>"},
+		},
+		{
+			desc: "Field transforms run after the defaults, including nested rules",
+			setup: func(cfg *config.Config) {
+				cfg.DataFormatting.RemoveHTML = true
+				cfg.ParseRules.Fields = map[string]config.FieldRule{
+					"name": {Selector: "h1", Transforms: []config.TransformSpec{{Name: "testShout"}}},
+					"reviews": {
+						Selector: ".review",
+						Multiple: true,
+						Fields: map[string]config.FieldRule{
+							"body": {Selector: "p", Transforms: []config.TransformSpec{{Name: "testShout"}}},
+						},
+					},
+				}
+			},
+			fields: map[string]interface{}{
+				"name": "<em>hi</em>",
+				"reviews": []map[string]interface{}{
+					{"body": "<b>good</b>"},
+					{"body": "<br>"},
+				},
+				"unconfigured": "<b>kept</b>",
+			},
+			expected: map[string]interface{}{
+				"name":         "HI!",
+				"reviews":      []map[string]interface{}{{"body": "GOOD!"}},
+				"unconfigured": "<b>kept</b>",
+			},
+		},
+		{
+			desc: "Transform errors are reported",
+			setup: func(cfg *config.Config) {
+				cfg.ParseRules.Fields = map[string]config.FieldRule{
+					"name": {Selector: "h1", Transforms: []config.TransformSpec{{Name: "testFail"}}},
+				}
+			},
+			fields:    map[string]interface{}{"name": "x"},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cfg := &config.Config{}
+			tc.setup(cfg)
+			pp, err := NewPostProcessor(cfg)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			record := &Record{Fields: tc.fields}
+			err = pp.Process(record)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("Expected error, got %v", record.Fields)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(record.Fields, tc.expected) {
+				t.Errorf("Expected %#v, got %#v", tc.expected, record.Fields)
+			}
+		})
+	}
+}
+
+// TestPostProcessorKeepsFailedFields verifies that a failing transform leaves its field
+// unconverted and reports it without affecting other fields, and that missing fields
+// with a default are filled in.
+func TestPostProcessorKeepsFailedFields(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.DataFormatting.CleanWhitespace = true
+	cfg.ParseRules.Fields = map[string]config.FieldRule{
+		"price":  {Selector: ".price", Transforms: []config.TransformSpec{{Name: "toNumber"}}},
+		"rating": {Selector: ".rating", Transforms: []config.TransformSpec{{Name: "toNumber"}}},
+		"stock":  {Selector: ".stock", Transforms: []config.TransformSpec{{Name: "default", Value: "unknown"}}},
+		"title":  {Selector: "h1", Transforms: []config.TransformSpec{{Name: "upper"}}},
+	}
+	pp, err := NewPostProcessor(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	record := &Record{Fields: map[string]interface{}{"price": " Call  us ", "rating": "4.5", "title": "widget"}}
+	err = pp.Process(record)
+	if err == nil || !strings.Contains(err.Error(), `field price: cannot parse "Call us" as a number`) {
+		t.Errorf("Expected a warning for price, got %v", err)
+	}
+	expected := map[string]interface{}{"price": "Call us", "rating": 4.5, "stock": "unknown", "title": "WIDGET"}
+	if !reflect.DeepEqual(record.Fields, expected) {
+		t.Errorf("Expected %#v, got %#v", expected, record.Fields)
+	}
+}
+
+// TestParseAndProcess verifies that element values are text unless markup is needed,
+// and that entities are decoded either way.
+func TestParseAndProcess(t *testing.T) {
+	page := `<h2 class="name">Tom &amp;amp; <b>Jerry</b></h2><div class="body"><p>One</p><p>Two &amp;amp; three</p></div>` +
+		"<div class=\"code\"><pre>func f() {\n\treturn 1\n}</pre></div>"
+	cases := []struct {
+		desc     string
+		setup    func(cfg *config.Config)
+		expected map[string]interface{}
+	}{
+		{
+			desc:     "Text by default",
+			setup:    func(cfg *config.Config) {},
+			expected: map[string]interface{}{"name": "Tom & Jerry", "body": "OneTwo & three"},
+		},
+		{
+			desc:     "RemoveHTML keeps paragraph breaks",
+			setup:    func(cfg *config.Config) { cfg.DataFormatting.RemoveHTML = true },
+			expected: map[string]interface{}{"name": "Tom & Jerry", "body": "One\n\nTwo & three"},
+		},
+		{
+			desc: "Markup transforms receive the HTML",
+			setup: func(cfg *config.Config) {
+				cfg.ParseRules.Fields["body"] = config.FieldRule{Selector: ".body", Transforms: []config.TransformSpec{{Name: "toMarkdown"}}}
+			},
+			expected: map[string]interface{}{"name": "Tom & Jerry", "body": "One\n\nTwo &amp; three"},
+		},
+		{
+			desc: "CleanWhitespace keeps pre indentation in Markdown",
+			setup: func(cfg *config.Config) {
+				cfg.DataFormatting.CleanWhitespace = true
+				cfg.ParseRules.Fields["code"] = config.FieldRule{Selector: ".code", Transforms: []config.TransformSpec{{Name: "toMarkdown"}}}
+			},
+			expected: map[string]interface{}{"name": "Tom & Jerry", "body": "OneTwo & three", "code": "```\nfunc f() {\n\treturn 1\n}\n```"},
+		},
+		{
+			desc: "CleanWhitespace keeps pre indentation in text",
+			setup: func(cfg *config.Config) {
+				cfg.DataFormatting.CleanWhitespace = true
+				cfg.DataFormatting.RemoveHTML = true
+				cfg.ParseRules.Fields["code"] = config.FieldRule{Selector: ".code"}
+			},
+			expected: map[string]interface{}{"name": "Tom & Jerry", "body": "One\n\nTwo & three", "code": "func f() {\n\treturn 1\n}"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.ParseRules.Fields = map[string]config.FieldRule{
+				"name": {Selector: ".name"},
+				"body": {Selector: ".body"},
+			}
+			tc.setup(cfg)
+			pp, err := NewPostProcessor(cfg)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			records, err := ParseHTML(page, "", cfg)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if err := pp.Process(records[0]); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(records[0].Fields, tc.expected) {
+				t.Errorf("Expected %#v, got %#v", tc.expected, records[0].Fields)
+			}
+		})
+	}
+}
+
+// TestNewPostProcessorUnknownTransform verifies that unknown transform names are rejected up front.
+func TestNewPostProcessorUnknownTransform(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ParseRules.Fields = map[string]config.FieldRule{
+		"items": {
+			Selector: ".item",
+			Fields: map[string]config.FieldRule{
+				"name": {Selector: "h2", Transforms: []config.TransformSpec{{Name: "nope"}}},
+			},
+		},
+	}
+	_, err := NewPostProcessor(cfg)
+	if err == nil || !strings.Contains(err.Error(), `items.name: unknown transform "nope"`) {
+		t.Errorf("Expected unknown transform error, got %v", err)
+	}
+}
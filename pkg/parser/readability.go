@@ -0,0 +1,206 @@
+// File: pkg/parser/readability.go
+
+package parser
+
+import (
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// boilerplateElements never hold main content.
+var boilerplateElements = "script, style, noscript, template, iframe, svg, form, button, select, " +
+	"nav, footer, aside, header, [role=navigation], [role=banner], [role=contentinfo], " +
+	"[role=complementary], [aria-hidden=true], [hidden]"
+
+// unlikelyClass and likelyClass match class and id tokens of boilerplate and content containers.
+var (
+	unlikelyClass = regexp.MustCompile(`(?i)(^|[^a-z])(ad|ads|adv|advert\w*|banner|breadcrumbs?|comments?|cookies?|disqus|footer|masthead|menu|modal|nav\w*|newsletter|outbrain|pager|pagination|popup|promo\w*|related|share|sharing|sidebar|social|sponsor\w*|subscribe|tags?|taboola|toolbar|widget)([^a-z]|$)`)
+	likelyClass   = regexp.MustCompile(`(?i)(^|[^a-z])(article|body|content|entry|main|page|post|story|text)([^a-z]|$)`)
+)
+
+// paragraphElements are scored for the text they hold.
+var paragraphElements = "p, pre, td, blockquote, li, div, section"
+
+// blockChildren are elements whose presence means a div is a container rather than a paragraph.
+var blockChildren = "p, div, section, article, table, ul, ol, pre, blockquote, h1, h2, h3, h4, h5, h6"
+
+/*
+MainContent finds the main content of a page with Readability-style scoring.
+
+Parameters:
+  - doc: The parsed page. It is not modified.
+  - pageURL: The address of the page; relative links and images in the content are
+    resolved against it (or the document's <base href>). May be empty.
+
+Returns:
+  - The inner HTML of the element most likely to hold the article body, followed by any
+    sibling elements that score nearly as well, or "" if no element holds enough text.
+
+Notes:
+  - Navigation, headers, footers, sidebars, forms, scripts and elements whose class or id
+    suggests ads, comments, sharing or related links are discarded before scoring.
+  - Each paragraph scores by text length and comma count, credited to its parent and
+    (half) grandparent. Containers are weighted by tag and class/id, then scaled by
+    (1 - link density), so link lists rank below prose.
+*/
+func MainContent(doc *goquery.Document, pageURL string) string {
+	return mainContent(doc, documentBase(doc, pageURL))
+}
+
+// mainContent implements MainContent for a resolved base URL.
+func mainContent(doc *goquery.Document, base *url.URL) string {
+	root := doc.Selection.Clone()
+	removeBoilerplate(root)
+
+	scores := make(map[*html.Node]float64)
+	var candidates []*goquery.Selection
+	root.Find(paragraphElements).Each(func(_ int, s *goquery.Selection) {
+		tag := goquery.NodeName(s)
+		if (tag == "div" || tag == "section") && s.ChildrenFiltered(blockChildren).Length() > 0 {
+			return
+		}
+		text := strings.TrimSpace(s.Text())
+		if len([]rune(text)) < 25 {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len([]rune(text)))/100, 3)
+		for i, ancestor := range []*goquery.Selection{s.Parent(), s.Parent().Parent()} {
+			if ancestor.Length() == 0 || ancestor.Is("html, body") {
+				break
+			}
+			node := ancestor.Get(0)
+			if _, ok := scores[node]; !ok {
+				scores[node] = initialScore(ancestor)
+				candidates = append(candidates, ancestor)
+			}
+			scores[node] += score / float64(i+1)
+		}
+	})
+
+	var top *goquery.Selection
+	best := 0.0
+	for _, c := range candidates {
+		node := c.Get(0)
+		scores[node] *= 1 - linkDensity(c)
+		if scores[node] > best {
+			top, best = c, scores[node]
+		}
+	}
+	if top == nil {
+		return ""
+	}
+
+	// Paragraphs of one article are sometimes split across sibling containers.
+	threshold := math.Max(10, best*0.2)
+	var parts []*goquery.Selection
+	top.Parent().Children().Each(func(_ int, s *goquery.Selection) {
+		node := s.Get(0)
+		include := node == top.Get(0) || scores[node] >= threshold
+		if !include && goquery.NodeName(s) == "p" {
+			include = len([]rune(strings.TrimSpace(s.Text()))) > 80 && linkDensity(s) < 0.25
+		}
+		if include {
+			cleanContent(s, base)
+			parts = append(parts, s)
+		}
+	})
+	if len(parts) == 1 {
+		h, _ := top.Html()
+		return strings.TrimSpace(h)
+	}
+	var b strings.Builder
+	for _, s := range parts {
+		h, _ := goquery.OuterHtml(s)
+		b.WriteString(h)
+		b.WriteString("\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// removeBoilerplate deletes elements that are never part of the main content.
+func removeBoilerplate(root *goquery.Selection) {
+	root.Find(boilerplateElements).Remove()
+	root.Find("[class], [id]").Each(func(_ int, s *goquery.Selection) {
+		if s.Is("html, body, article, main") {
+			return
+		}
+		class, _ := s.Attr("class")
+		id, _ := s.Attr("id")
+		names := class + " " + id
+		if unlikelyClass.MatchString(names) && !likelyClass.MatchString(names) {
+			s.Remove()
+		}
+	})
+}
+
+// initialScore weights a candidate container by its tag and class/id names.
+func initialScore(s *goquery.Selection) float64 {
+	score := 0.0
+	switch goquery.NodeName(s) {
+	case "article", "main":
+		score += 10
+	case "div", "section":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+	class, _ := s.Attr("class")
+	id, _ := s.Attr("id")
+	names := class + " " + id
+	if likelyClass.MatchString(names) {
+		score += 25
+	}
+	if unlikelyClass.MatchString(names) {
+		score -= 25
+	}
+	return score
+}
+
+// linkDensity is the share of s's text that sits inside links.
+func linkDensity(s *goquery.Selection) float64 {
+	total := len([]rune(strings.TrimSpace(s.Text())))
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		links += len([]rune(strings.TrimSpace(a.Text())))
+	})
+	return math.Min(float64(links)/float64(total), 1)
+}
+
+// cleanContent removes link-heavy lists and tables and empty paragraphs from chosen content,
+// and resolves relative links and image sources against base.
+func cleanContent(s *goquery.Selection, base *url.URL) {
+	s.Find("ul, ol, table, div, section").Each(func(_ int, c *goquery.Selection) {
+		if c.Find("img, pre").Length() == 0 && linkDensity(c) > 0.5 {
+			c.Remove()
+		}
+	})
+	s.Find("p").Each(func(_ int, p *goquery.Selection) {
+		if strings.TrimSpace(p.Text()) == "" && p.Find("img").Length() == 0 {
+			p.Remove()
+		}
+	})
+	if base == nil {
+		return
+	}
+	s.Find("[href], [src]").Each(func(_ int, e *goquery.Selection) {
+		for _, attr := range []string{"href", "src"} {
+			if v, ok := e.Attr(attr); ok {
+				if ref, err := url.Parse(strings.TrimSpace(v)); err == nil {
+					e.SetAttr(attr, base.ResolveReference(ref).String())
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,108 @@
+// File: pkg/parser/readability_test.go
+
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+)
+
+// articlePage has no <article> element: the body sits in a div among navigation, a sidebar,
+// share links, ads and a footer.
+const articlePage = `<html><head><title>Story</title><script>track()</script></head><body>
+<header><a href="/">Home</a> <a href="/news">News</a></header>
+<nav class="menu"><ul><li><a href="/a">Section A, with a long enough link label</a></li><li><a href="/b">Section B, with a long enough link label</a></li></ul></nav>
+<div id="page">
+  <div class="sidebar"><p>Popular stories you might have missed this week, and more.</p></div>
+  <div class="post-body">
+    <h1>Rivers rising</h1>
+    <p>Heavy rain over the weekend pushed rivers in the region, already swollen by snowmelt, past flood stage.</p>
+    <p>Officials said the water would crest on Tuesday, and urged residents near the banks to move valuables upstairs.</p>
+    <p>See the <a href="/maps/flood">flood map</a> for affected streets, updated hourly by the county.</p>
+    <ul class="share"><li><a href="/share/x">Share on X</a></li><li><a href="/share/fb">Share on Facebook</a></li></ul>
+    <div class="ad-slot"><p>Buy one, get one free, at participating stores near you today.</p></div>
+    <p></p>
+  </div>
+</div>
+<footer><p>Copyright 2025 The Daily Example, all rights reserved, and so on.</p></footer>
+</body></html>`
+
+// TestMainContent verifies that the article body is found and boilerplate is removed.
+func TestMainContent(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(articlePage))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	content := MainContent(doc, "https://example.com/story/1")
+
+	for _, want := range []string{"<h1>Rivers rising</h1>", "past flood stage", "crest on Tuesday", `href="https://example.com/maps/flood"`} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Expected content to contain %q, got %q", want, content)
+		}
+	}
+	for _, unwanted := range []string{"Section A", "Popular stories", "Share on", "Buy one", "Copyright", "track()", "<p></p>"} {
+		if strings.Contains(content, unwanted) {
+			t.Errorf("Expected content not to contain %q, got %q", unwanted, content)
+		}
+	}
+	if doc.Find("nav").Length() != 1 {
+		t.Error("Expected the document to be left unmodified")
+	}
+}
+
+// TestMainContentNoText verifies that pages without prose yield no content.
+func TestMainContentNoText(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<html><body><nav><a href="/">Home</a></nav><div>Hi</div></body></html>`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if content := MainContent(doc, ""); content != "" {
+		t.Errorf("Expected no content, got %q", content)
+	}
+}
+
+// TestArticleContentFallback verifies that ParseHTML falls back to the main content when the
+// articleContent selector matches nothing, and that ContentFormat converts it.
+func TestArticleContentFallback(t *testing.T) {
+	cases := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format:   config.ContentMarkdown,
+			expected: "# Rivers rising\n\nHeavy rain over the weekend pushed rivers in the region, already swollen by snowmelt, past flood stage.",
+		},
+		{
+			format:   config.ContentText,
+			expected: "Rivers rising\n\nHeavy rain over the weekend pushed rivers in the region, already swollen by snowmelt, past flood stage.",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.format, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.ParseRules.ArticleContent = "article"
+			cfg.DataFormatting.ContentFormat = tc.format
+			cfg.DataFormatting.RemoveHTML = true
+			records, err := ParseHTML(articlePage, "https://example.com/story/1", cfg)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			record := records[0]
+			pp, err := NewPostProcessor(cfg)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if err := pp.Process(record); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			content, _ := record.Fields["articleContent"].(string)
+			if !strings.HasPrefix(content, tc.expected) {
+				t.Errorf("Expected content starting with %q, got %q", tc.expected, content)
+			}
+		})
+	}
+}
@@ -0,0 +1,373 @@
+// File: pkg/parser/structured.go
+
+package parser
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+	"golang.org/x/net/html"
+)
+
+/*
+StructuredData holds the machine-readable metadata embedded in a page.
+
+Fields:
+  - JSONLD: Objects decoded from <script type="application/ld+json"> blocks, with
+    top-level arrays and @graph containers flattened.
+  - Microdata: Top-level itemscope items. Each item maps "@type" to its type name
+    (e.g. "Product") and every itemprop to its value, a nested item, or a list of those.
+  - RDFa: Top-level typeof items, in the same shape as Microdata.
+  - OpenGraph: Prefixed <meta> property values keyed by full property name (e.g. "og:title",
+    "article:published_time"), in document order.
+  - Twitter: Twitter Card <meta> values keyed by full name (e.g. "twitter:card").
+
+Notes:
+  - Type and property names drop vocabulary prefixes, so "https://schema.org/Product"
+    and "schema:Product" are both stored as "Product".
+*/
+type StructuredData struct {
+	JSONLD    []map[string]interface{} `json:"jsonld,omitempty"`
+	Microdata []map[string]interface{} `json:"microdata,omitempty"`
+	RDFa      []map[string]interface{} `json:"rdfa,omitempty"`
+	OpenGraph map[string][]string      `json:"opengraph,omitempty"`
+	Twitter   map[string][]string      `json:"twitter,omitempty"`
+}
+
+/*
+ExtractStructuredData collects JSON-LD, Microdata, RDFa, OpenGraph and Twitter Card
+metadata from doc.
+
+Parameters:
+  - doc: The parsed page.
+
+Returns:
+  - The collected data, or nil if the page embeds none.
+
+Notes:
+  - Malformed JSON-LD blocks are skipped.
+*/
+func ExtractStructuredData(doc *goquery.Document) *StructuredData {
+	sd := &StructuredData{
+		JSONLD:    extractJSONLD(doc),
+		Microdata: extractItems(doc, microdataScheme),
+		RDFa:      extractItems(doc, rdfaScheme),
+		OpenGraph: make(map[string][]string),
+		Twitter:   make(map[string][]string),
+	}
+
+	doc.Find("meta[content]").Each(func(_ int, sel *goquery.Selection) {
+		content := strings.TrimSpace(sel.AttrOr("content", ""))
+		if content == "" {
+			return
+		}
+		name := strings.TrimSpace(sel.AttrOr("property", ""))
+		if name == "" {
+			name = strings.TrimSpace(sel.AttrOr("name", ""))
+		}
+		switch {
+		case strings.HasPrefix(name, "twitter:"):
+			sd.Twitter[name] = append(sd.Twitter[name], content)
+		case strings.Contains(name, ":"):
+			sd.OpenGraph[name] = append(sd.OpenGraph[name], content)
+		}
+	})
+
+	if len(sd.JSONLD) == 0 && len(sd.Microdata) == 0 && len(sd.RDFa) == 0 &&
+		len(sd.OpenGraph) == 0 && len(sd.Twitter) == 0 {
+		return nil
+	}
+	return sd
+}
+
+/*
+Lookup resolves a structured-data reference to its values.
+
+Parameters:
+  - ref: A reference accepted by config.ParseReference, e.g. "jsonld:Article.author.name".
+
+Returns:
+  - Every non-empty string, number or boolean found, in document order.
+    Item references match objects of the named type at any depth.
+*/
+func (sd *StructuredData) Lookup(ref string) []string {
+	if sd == nil {
+		return nil
+	}
+	source, key, err := config.ParseReference(ref)
+	if err != nil {
+		return nil
+	}
+	switch source {
+	case "jsonld":
+		return lookupItems(sd.JSONLD, key)
+	case "microdata":
+		return lookupItems(sd.Microdata, key)
+	case "rdfa":
+		return lookupItems(sd.RDFa, key)
+	case "twitter":
+		return sd.Twitter[key]
+	default:
+		return sd.OpenGraph[key]
+	}
+}
+
+// lookupItems walks key ("Type.path.to.value") through every object of Type in items.
+func lookupItems(items []map[string]interface{}, key string) []string {
+	path := strings.Split(key, ".")
+	var matches []interface{}
+	for _, item := range items {
+		matches = append(matches, findTyped(item, path[0])...)
+	}
+
+	for _, name := range path[1:] {
+		var next []interface{}
+		for _, v := range matches {
+			if m, ok := v.(map[string]interface{}); ok {
+				next = append(next, flatten(m[name])...)
+			}
+		}
+		matches = next
+	}
+
+	var values []string
+	for _, v := range flatten(matches) {
+		if s, ok := scalarString(v); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// findTyped returns v and every object nested in it whose @type matches itemType ("*" matches
+// any type). Object keys are visited in sorted order so results are deterministic.
+func findTyped(v interface{}, itemType string) []interface{} {
+	var found []interface{}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if t, ok := v["@type"]; ok && (itemType == "*" || hasType(t, itemType)) {
+			found = append(found, v)
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			if key != "@type" {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			found = append(found, findTyped(v[key], itemType)...)
+		}
+	case []interface{}:
+		for _, child := range v {
+			found = append(found, findTyped(child, itemType)...)
+		}
+	}
+	return found
+}
+
+// hasType reports whether an @type value (a string or a list of strings) names itemType.
+func hasType(t interface{}, itemType string) bool {
+	for _, v := range flatten(t) {
+		if s, ok := v.(string); ok && localName(s) == itemType {
+			return true
+		}
+	}
+	return false
+}
+
+// flatten expands a list value into its elements; nil yields nothing.
+func flatten(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		var out []interface{}
+		for _, e := range v {
+			out = append(out, flatten(e)...)
+		}
+		return out
+	}
+	return []interface{}{v}
+}
+
+// scalarString formats a leaf value, unwrapping JSON-LD {"@value": ...} objects.
+func scalarString(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		v = strings.TrimSpace(v)
+		return v, v != ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case map[string]interface{}:
+		if inner, ok := v["@value"]; ok {
+			return scalarString(inner)
+		}
+	}
+	return "", false
+}
+
+// localName strips a vocabulary URL or CURIE prefix from a type or property name.
+func localName(name string) string {
+	name = strings.TrimSpace(name)
+	if i := strings.LastIndexAny(name, "/#:"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// extractJSONLD decodes every JSON-LD block in doc into a flat list of objects.
+func extractJSONLD(doc *goquery.Document) []map[string]interface{} {
+	var objects []map[string]interface{}
+	var add func(v interface{})
+	add = func(v interface{}) {
+		switch v := v.(type) {
+		case []interface{}:
+			for _, e := range v {
+				add(e)
+			}
+		case map[string]interface{}:
+			if graph, ok := v["@graph"]; ok {
+				add(graph)
+				return
+			}
+			objects = append(objects, v)
+		}
+	}
+
+	doc.Find(`script[type="application/ld+json"]`).Each(func(_ int, sel *goquery.Selection) {
+		var v interface{}
+		if err := json.Unmarshal([]byte(sel.Text()), &v); err == nil {
+			add(v)
+		}
+	})
+	return objects
+}
+
+/*
+itemScheme describes the attributes an item vocabulary is encoded with.
+
+Fields:
+  - scope: The attribute marking an element as an item.
+  - typeAttr: The attribute holding the item's type(s).
+  - prop: The attribute naming an element's property (or properties).
+  - value: Returns the value of a property element that is not itself an item.
+*/
+type itemScheme struct {
+	scope    string
+	typeAttr string
+	prop     string
+	value    func(sel *goquery.Selection) string
+}
+
+// microdataScheme reads HTML Microdata (itemscope/itemtype/itemprop).
+var microdataScheme = itemScheme{
+	scope:    "itemscope",
+	typeAttr: "itemtype",
+	prop:     "itemprop",
+	value: func(sel *goquery.Selection) string {
+		switch goquery.NodeName(sel) {
+		case "meta":
+			return sel.AttrOr("content", "")
+		case "a", "area", "link":
+			return sel.AttrOr("href", "")
+		case "img", "audio", "video", "source", "iframe", "embed", "track":
+			return sel.AttrOr("src", "")
+		case "object":
+			return sel.AttrOr("data", "")
+		case "time":
+			return sel.AttrOr("datetime", sel.Text())
+		case "data", "meter":
+			return sel.AttrOr("value", "")
+		}
+		return sel.Text()
+	},
+}
+
+// rdfaScheme reads RDFa Lite (typeof/property).
+var rdfaScheme = itemScheme{
+	scope:    "typeof",
+	typeAttr: "typeof",
+	prop:     "property",
+	value: func(sel *goquery.Selection) string {
+		for _, attr := range []string{"content", "resource", "href", "src", "datetime"} {
+			if v, ok := sel.Attr(attr); ok {
+				return v
+			}
+		}
+		return sel.Text()
+	},
+}
+
+// extractItems collects the top-level items of scheme in doc.
+func extractItems(doc *goquery.Document, scheme itemScheme) []map[string]interface{} {
+	var items []map[string]interface{}
+	doc.Find("[" + scheme.scope + "]").Each(func(_ int, sel *goquery.Selection) {
+		if _, isProp := sel.Attr(scheme.prop); isProp {
+			return
+		}
+		if ownerItem(sel, scheme) != nil {
+			return
+		}
+		items = append(items, readItem(sel, scheme))
+	})
+	return items
+}
+
+// readItem converts an item element and its properties into a map.
+func readItem(item *goquery.Selection, scheme itemScheme) map[string]interface{} {
+	object := make(map[string]interface{})
+	if types := strings.Fields(item.AttrOr(scheme.typeAttr, "")); len(types) > 0 {
+		names := make([]interface{}, len(types))
+		for i, t := range types {
+			names[i] = localName(t)
+		}
+		if len(names) == 1 {
+			object["@type"] = names[0]
+		} else {
+			object["@type"] = names
+		}
+	}
+
+	self := item.Get(0)
+	item.Find("[" + scheme.prop + "]").Each(func(_ int, sel *goquery.Selection) {
+		if ownerItem(sel, scheme) != self {
+			return
+		}
+		var value interface{}
+		if _, isItem := sel.Attr(scheme.scope); isItem {
+			value = readItem(sel, scheme)
+		} else if s := strings.TrimSpace(scheme.value(sel)); s != "" {
+			value = s
+		} else {
+			return
+		}
+		for _, name := range strings.Fields(sel.AttrOr(scheme.prop, "")) {
+			name = localName(name)
+			switch existing := object[name].(type) {
+			case nil:
+				object[name] = value
+			case []interface{}:
+				object[name] = append(existing, value)
+			default:
+				object[name] = []interface{}{existing, value}
+			}
+		}
+	})
+	return object
+}
+
+// ownerItem returns the nearest ancestor of sel that is an item of scheme, or nil.
+func ownerItem(sel *goquery.Selection, scheme itemScheme) *html.Node {
+	owner := sel.Parent().Closest("[" + scheme.scope + "]")
+	if owner.Length() == 0 {
+		return nil
+	}
+	return owner.Get(0)
+}
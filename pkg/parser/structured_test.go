@@ -0,0 +1,152 @@
+// File: pkg/parser/structured_test.go
+
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const structuredPage = `<html>
+<head>
+	<meta property="og:title" content="OG Title">
+	<meta property="og:image" content="https://example.com/a.png">
+	<meta property="og:image" content="https://example.com/b.png">
+	<meta property="article:published_time" content="2024-03-01T10:00:00Z">
+	<meta name="twitter:card" content="summary">
+	<meta name="description" content="Not structured">
+	<script type="application/ld+json">
+	{
+		"@context": "https://schema.org",
+		"@graph": [
+			{"@type": "NewsArticle", "headline": "LD Headline", "author": [{"@type": "Person", "name": "Ada"}, {"@type": "Person", "name": "Grace"}]},
+			{"@type": ["Product", "Thing"], "sku": 42, "offers": {"@type": "Offer", "price": {"@value": "9.99"}}}
+		]
+	}
+	</script>
+	<script type="application/ld+json">{ not json</script>
+</head>
+<body>
+	<div itemscope itemtype="https://schema.org/Recipe">
+		<h1 itemprop="name">Pancakes</h1>
+		<a itemprop="url" href="/pancakes">link</a>
+		<time itemprop="cookTime" datetime="PT20M">20 minutes</time>
+		<span itemprop="recipeIngredient">Flour</span>
+		<span itemprop="recipeIngredient">Milk</span>
+		<div itemprop="author" itemscope itemtype="https://schema.org/Person">
+			<span itemprop="name">Chef</span>
+		</div>
+	</div>
+	<div vocab="https://schema.org/" typeof="Event">
+		<span property="name">Launch</span>
+		<meta property="startDate" content="2024-05-01">
+		<div property="location" typeof="Place"><span property="name">Berlin</span></div>
+	</div>
+</body>
+</html>`
+
+// TestExtractStructuredData verifies that every supported format is collected into StructuredData.
+func TestExtractStructuredData(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(structuredPage))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sd := ExtractStructuredData(doc)
+	if sd == nil {
+		t.Fatal("Expected structured data, got nil")
+	}
+
+	if len(sd.JSONLD) != 2 {
+		t.Errorf("Expected 2 JSON-LD objects from @graph, got %d", len(sd.JSONLD))
+	}
+	expectedOG := map[string][]string{
+		"og:title":               {"OG Title"},
+		"og:image":               {"https://example.com/a.png", "https://example.com/b.png"},
+		"article:published_time": {"2024-03-01T10:00:00Z"},
+	}
+	if !reflect.DeepEqual(sd.OpenGraph, expectedOG) {
+		t.Errorf("Expected OpenGraph %v, got %v", expectedOG, sd.OpenGraph)
+	}
+	if !reflect.DeepEqual(sd.Twitter, map[string][]string{"twitter:card": {"summary"}}) {
+		t.Errorf("Unexpected Twitter data: %v", sd.Twitter)
+	}
+
+	expectedMicrodata := []map[string]interface{}{{
+		"@type":            "Recipe",
+		"name":             "Pancakes",
+		"url":              "/pancakes",
+		"cookTime":         "PT20M",
+		"recipeIngredient": []interface{}{"Flour", "Milk"},
+		"author":           map[string]interface{}{"@type": "Person", "name": "Chef"},
+	}}
+	if !reflect.DeepEqual(sd.Microdata, expectedMicrodata) {
+		t.Errorf("Expected Microdata %v, got %v", expectedMicrodata, sd.Microdata)
+	}
+
+	expectedRDFa := []map[string]interface{}{{
+		"@type":     "Event",
+		"name":      "Launch",
+		"startDate": "2024-05-01",
+		"location":  map[string]interface{}{"@type": "Place", "name": "Berlin"},
+	}}
+	if !reflect.DeepEqual(sd.RDFa, expectedRDFa) {
+		t.Errorf("Expected RDFa %v, got %v", expectedRDFa, sd.RDFa)
+	}
+}
+
+// TestExtractStructuredDataEmpty verifies that a page without metadata yields nil.
+func TestExtractStructuredDataEmpty(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<p>plain</p>`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if sd := ExtractStructuredData(doc); sd != nil {
+		t.Errorf("Expected nil, got %+v", sd)
+	}
+}
+
+// TestStructuredDataLookup verifies reference resolution across sources.
+func TestStructuredDataLookup(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(structuredPage))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sd := ExtractStructuredData(doc)
+
+	cases := []struct {
+		ref      string
+		expected []string
+	}{
+		{"jsonld:NewsArticle.headline", []string{"LD Headline"}},
+		{"jsonld:*.author.name", []string{"Ada", "Grace"}},
+		{"jsonld:Person.name", []string{"Ada", "Grace"}},
+		{"jsonld:Thing.sku", []string{"42"}},
+		{"jsonld:Product.offers.price", []string{"9.99"}},
+		{"jsonld:Product.missing", nil},
+		{"microdata:Recipe.recipeIngredient", []string{"Flour", "Milk"}},
+		{"microdata:Recipe.author.name", []string{"Chef"}},
+		{"rdfa:Event.location.name", []string{"Berlin"}},
+		{"rdfa:Place.name", []string{"Berlin"}},
+		{"og:image", []string{"https://example.com/a.png", "https://example.com/b.png"}},
+		{"article:published_time", []string{"2024-03-01T10:00:00Z"}},
+		{"twitter:card", []string{"summary"}},
+		{"og:missing", nil},
+		{"bogus:title", nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.ref, func(t *testing.T) {
+			if got := sd.Lookup(tc.ref); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+
+	var empty *StructuredData
+	if got := empty.Lookup("og:title"); got != nil {
+		t.Errorf("Expected nil lookup on nil data, got %v", got)
+	}
+}
@@ -0,0 +1,209 @@
+// File: pkg/parser/table.go
+
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+/*
+Limits that keep malformed or hostile markup from allocating huge grids.
+
+  - maxSpan: Largest colspan or rowspan honored; larger values are clamped.
+  - maxTableCells: Largest number of grid slots a table may expand to.
+*/
+const (
+	maxSpan       = 100
+	maxTableCells = 100000
+)
+
+/*
+tableCell is one slot of a table's layout grid.
+
+Fields:
+  - text: The cell's visible text.
+  - header: Whether the cell is a <th> or sits in <thead>.
+  - origin: The cell a spanning slot was copied from, so repeated slots can be recognized.
+*/
+type tableCell struct {
+	text   string
+	header bool
+	origin *html.Node
+}
+
+/*
+extractTable converts a <table> element into one record per data row.
+
+Parameters:
+  - table: The <table> node.
+
+Returns:
+  - The rows, each mapping a column header to the cell text. Empty cells are omitted,
+    as are rows with no text.
+  - An error if the table expands to more than maxTableCells slots.
+
+Notes:
+  - Cells spanning several columns or rows (colspan, rowspan) fill every slot they cover.
+  - Leading rows in <thead>, or made up only of <th> cells, are headers. With several header
+    rows, a column's name joins the distinct texts above it with " / " (e.g. "Price / Monthly").
+  - Columns without a header are named "column1", "column2", ...; repeated names get a
+    numeric suffix ("Price 2").
+  - Rows of tables nested inside a cell are not read separately.
+*/
+func extractTable(table *html.Node) ([]map[string]interface{}, error) {
+	grid, err := tableGrid(table)
+	if err != nil {
+		return nil, err
+	}
+
+	headerRows := 0
+	for headerRows < len(grid) && isHeaderRow(grid[headerRows]) {
+		headerRows++
+	}
+	if headerRows == len(grid) && headerRows > 0 {
+		headerRows = 0 // A table of only <th> cells holds data, not headers.
+	}
+
+	width := 0
+	for _, row := range grid {
+		width = max(width, len(row))
+	}
+	names := columnNames(grid[:headerRows], width)
+
+	var rows []map[string]interface{}
+	for _, row := range grid[headerRows:] {
+		record := make(map[string]interface{})
+		for i, cell := range row {
+			if cell != nil && cell.text != "" {
+				record[names[i]] = cell.text
+			}
+		}
+		if len(record) > 0 {
+			rows = append(rows, record)
+		}
+	}
+	return rows, nil
+}
+
+// tableGrid lays out the rows of table, expanding colspan and rowspan into repeated slots.
+// It stops with an error once the grid would hold more than maxTableCells slots.
+func tableGrid(table *html.Node) ([][]*tableCell, error) {
+	var grid [][]*tableCell
+	cells := 0
+	for r, tr := range tableRows(table, false) {
+		for len(grid) <= r {
+			grid = append(grid, nil)
+		}
+		col := 0
+		for td := tr.node.FirstChild; td != nil; td = td.NextSibling {
+			if td.Type != html.ElementNode || (td.Data != "td" && td.Data != "th") {
+				continue
+			}
+			for col < len(grid[r]) && grid[r][col] != nil {
+				col++
+			}
+			cell := &tableCell{text: visibleText(td), header: td.Data == "th" || tr.head, origin: td}
+
+			colspan, rowspan := span(td, "colspan"), span(td, "rowspan")
+			for dr := 0; dr < rowspan; dr++ {
+				for len(grid) <= r+dr {
+					grid = append(grid, nil)
+				}
+				for dc := 0; dc < colspan; dc++ {
+					for len(grid[r+dr]) <= col+dc {
+						if cells++; cells > maxTableCells {
+							return nil, fmt.Errorf("table exceeds %d cells", maxTableCells)
+						}
+						grid[r+dr] = append(grid[r+dr], nil)
+					}
+					grid[r+dr][col+dc] = cell
+				}
+			}
+			col += colspan
+		}
+	}
+	return grid, nil
+}
+
+// tableRow is a <tr> and whether it belongs to <thead>.
+type tableRow struct {
+	node *html.Node
+	head bool
+}
+
+// tableRows returns the rows of n in document order, descending into row groups but not
+// into nested tables.
+func tableRows(n *html.Node, head bool) []tableRow {
+	var rows []tableRow
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		switch c.Data {
+		case "tr":
+			rows = append(rows, tableRow{node: c, head: head})
+		case "thead":
+			rows = append(rows, tableRows(c, true)...)
+		case "tbody", "tfoot":
+			rows = append(rows, tableRows(c, false)...)
+		}
+	}
+	return rows
+}
+
+// span returns the colspan or rowspan of a cell, defaulting to 1.
+func span(n *html.Node, key string) int {
+	v, err := strconv.Atoi(attr(n, key))
+	if err != nil || v < 1 {
+		return 1
+	}
+	return min(v, maxSpan)
+}
+
+// isHeaderRow reports whether every cell of row is a header cell.
+func isHeaderRow(row []*tableCell) bool {
+	found := false
+	for _, cell := range row {
+		if cell == nil {
+			continue
+		}
+		if !cell.header {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// columnNames derives a unique name for each of width columns from the header rows.
+func columnNames(headers [][]*tableCell, width int) []string {
+	names := make([]string, width)
+	seen := make(map[string]int)
+	for i := range names {
+		var parts []string
+		var last *html.Node
+		for _, row := range headers {
+			if i >= len(row) || row[i] == nil || row[i].text == "" || row[i].origin == last {
+				continue
+			}
+			last = row[i].origin
+			if len(parts) == 0 || parts[len(parts)-1] != row[i].text {
+				parts = append(parts, row[i].text)
+			}
+		}
+		name := strings.Join(parts, " / ")
+		if name == "" {
+			name = fmt.Sprintf("column%d", i+1)
+		}
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			name = fmt.Sprintf("%s %d", name, n)
+		}
+		names[i] = name
+	}
+	return names
+}
@@ -0,0 +1,144 @@
+// File: pkg/parser/table_test.go
+
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+)
+
+// TestTableRule verifies that table rules turn tables into rows keyed by column header.
+func TestTableRule(t *testing.T) {
+	cases := []struct {
+		desc     string
+		html     string
+		rule     config.FieldRule
+		expected interface{}
+	}{
+		{
+			desc: "simple header row",
+			html: `<table class="t"><tr><th>Plan</th><th>Price</th></tr>
+				<tr><td>Basic</td><td><b>$5</b></td></tr>
+				<tr><td>Pro</td><td>$10  per
+				month</td></tr></table>`,
+			rule: config.FieldRule{Selector: "table.t", Type: config.RuleTable},
+			expected: []map[string]interface{}{
+				{"Plan": "Basic", "Price": "$5"},
+				{"Plan": "Pro", "Price": "$10 per month"},
+			},
+		},
+		{
+			desc: "multi-row header with colspan and rowspan",
+			html: `<table class="t"><thead>
+				<tr><th rowspan="2">Plan</th><th colspan="2">Price</th></tr>
+				<tr><th>Monthly</th><th>Yearly</th></tr>
+				</thead><tbody>
+				<tr><td>Basic</td><td>$5</td><td>$50</td></tr>
+				<tr><td>Pro</td><td colspan="2">Contact us</td></tr>
+				</tbody></table>`,
+			rule: config.FieldRule{Selector: "table.t", Type: config.RuleTable},
+			expected: []map[string]interface{}{
+				{"Plan": "Basic", "Price / Monthly": "$5", "Price / Yearly": "$50"},
+				{"Plan": "Pro", "Price / Monthly": "Contact us", "Price / Yearly": "Contact us"},
+			},
+		},
+		{
+			desc: "rowspan in the body, empty and duplicate headers",
+			html: `<table class="t">
+				<tr><th>Region</th><th>City</th><th></th><th>City</th></tr>
+				<tr><td rowspan="2">North</td><td>Oslo</td><td>x</td><td></td></tr>
+				<tr><td>Bergen</td><td>y</td><td>z</td></tr>
+				<tr><td></td><td></td></tr>
+				</table>`,
+			rule: config.FieldRule{Selector: "table.t", Type: config.RuleTable},
+			expected: []map[string]interface{}{
+				{"Region": "North", "City": "Oslo", "column3": "x"},
+				{"Region": "North", "City": "Bergen", "column3": "y", "City 2": "z"},
+			},
+		},
+		{
+			desc: "no header row and row headers",
+			html: `<table class="t"><tr><th>CPU</th><td>M3</td></tr><tr><th>RAM</th><td>16 GB</td></tr></table>`,
+			rule: config.FieldRule{Selector: "table.t", Type: config.RuleTable},
+			expected: []map[string]interface{}{
+				{"column1": "CPU", "column2": "M3"},
+				{"column1": "RAM", "column2": "16 GB"},
+			},
+		},
+		{
+			desc: "nested tables stay inside their cell",
+			html: `<div class="t"><table><tr><th>A</th></tr><tr><td><table><tr><td>inner</td></tr></table></td></tr></table></div>`,
+			rule: config.FieldRule{Selector: "div.t", Type: config.RuleTable},
+			expected: []map[string]interface{}{
+				{"A": "inner"},
+			},
+		},
+		{
+			desc: "multiple tables are concatenated",
+			html: `<table class="t"><tr><th>N</th></tr><tr><td>1</td></tr></table>
+				<table class="t"><tr><th>N</th></tr><tr><td>2</td></tr></table>`,
+			rule: config.FieldRule{Selector: "table.t", Type: config.RuleTable, Multiple: true},
+			expected: []map[string]interface{}{
+				{"N": "1"},
+				{"N": "2"},
+			},
+		},
+		{
+			desc:     "no table",
+			html:     `<p>none</p>`,
+			rule:     config.FieldRule{Selector: "table.t", Type: config.RuleTable},
+			expected: nil,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.ParseRules.Fields = map[string]config.FieldRule{"rows": tc.rule}
+			records, err := ParseHTML("<html><body>"+tc.html+"</body></html>", "", cfg)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got, ok := records[0].Fields["rows"]
+			if tc.expected == nil {
+				if ok {
+					t.Errorf("Expected no rows, got %#v", got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Expected %#v, got %#v", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestTableRuleRequired verifies that a Required table rule fails when no table matches.
+func TestTableRuleRequired(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ParseRules.Fields = map[string]config.FieldRule{
+		"rows": {Selector: "table", Type: config.RuleTable, Required: true},
+	}
+	_, err := ParseHTML("<html><body><table></table></body></html>", "", cfg)
+	if err == nil || !strings.Contains(err.Error(), "required field rows") {
+		t.Errorf("Expected required field error, got %v", err)
+	}
+}
+
+// TestTableRuleTooLarge verifies that a table expanding past the cell budget fails
+// instead of allocating an unbounded grid.
+func TestTableRuleTooLarge(t *testing.T) {
+	cell := `<td colspan="5000" rowspan="5000">x</td>`
+	rows := strings.Repeat("<tr>"+strings.Repeat(cell, 20)+"</tr>", 100)
+	cfg := &config.Config{}
+	cfg.ParseRules.Fields = map[string]config.FieldRule{
+		"rows": {Selector: "table", Type: config.RuleTable},
+	}
+	_, err := ParseHTML("<html><body><table>"+rows+"</table></body></html>", "", cfg)
+	if err == nil || !strings.Contains(err.Error(), "table exceeds") {
+		t.Errorf("Expected cell budget error, got %v", err)
+	}
+}
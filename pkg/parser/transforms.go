@@ -0,0 +1,314 @@
+// File: pkg/parser/transforms.go
+
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+)
+
+/*
+Transform rewrites a single extracted string value.
+
+Returns:
+  - The rewritten value. An empty result removes the value from the record.
+  - An error if the value cannot be transformed.
+*/
+type Transform func(value string) (string, error)
+
+/*
+step is one compiled entry of a transform chain.
+
+Fields:
+  - scalar: Rewrites a single value (a string, float64 or time.Time). Returning a
+    []interface{} splits the value into several.
+  - list: When set, receives the whole list of values instead of scalar being applied
+    to each element.
+  - keepEmpty: Whether the step also runs when the value is empty or missing.
+*/
+type step struct {
+	scalar    func(value interface{}, ctx runContext) (interface{}, error)
+	list      func(values []interface{}) (interface{}, error)
+	keepEmpty bool
+}
+
+/*
+runContext carries per-record information to the steps of a chain.
+
+Fields:
+  - fetchedAt: When the record's page was fetched; relative dates are resolved against it.
+*/
+type runContext struct {
+	fetchedAt time.Time
+}
+
+// stepBuilder compiles a TransformSpec into a step.
+type stepBuilder func(spec config.TransformSpec, cfg *config.Config) (step, error)
+
+// transforms holds the simple named transforms available to FieldRule.Transforms.
+var (
+	transformsMu sync.RWMutex
+	transforms   = map[string]Transform{
+		"stripHTML":          stripHTML,
+		"collapseWhitespace": collapseWhitespace,
+		"decodeEntities":     decodeEntities,
+		"normalizeUnicode":   normalizeUnicode,
+		"toMarkdown":         toMarkdown,
+		"lower":              func(value string) (string, error) { return strings.ToLower(value), nil },
+		"upper":              func(value string) (string, error) { return strings.ToUpper(value), nil },
+	}
+)
+
+// stepBuilders holds the transforms that take parameters or produce non-string values.
+var stepBuilders = map[string]stepBuilder{
+	"regex":    buildRegex,
+	"replace":  buildReplace,
+	"trim":     buildTrim,
+	"split":    buildSplit,
+	"join":     buildJoin,
+	"toNumber": buildToNumber,
+	"toDate":   buildToDate,
+	"default":  buildDefault,
+}
+
+/*
+RegisterTransform makes fn available to FieldRule.Transforms under name, replacing any
+existing simple transform of that name.
+
+Usage:
+
+	parser.RegisterTransform("stripCurrency", func(v string) (string, error) {
+	    return strings.TrimLeft(v, "$€£"), nil
+	})
+
+Notes:
+  - The built-in parameterized transforms ("regex", "replace", "trim", "split", "join",
+    "toNumber", "toDate", "default") cannot be replaced.
+*/
+func RegisterTransform(name string, fn Transform) {
+	transformsMu.Lock()
+	defer transformsMu.Unlock()
+	transforms[name] = fn
+}
+
+// compileStep resolves spec to a step, preferring the built-in parameterized transforms.
+// cfg supplies defaults such as DataFormatting.DateLayouts.
+func compileStep(spec config.TransformSpec, cfg *config.Config) (step, error) {
+	if build, ok := stepBuilders[spec.Name]; ok {
+		s, err := build(spec, cfg)
+		if err != nil {
+			return step{}, fmt.Errorf("transform %q: %v", spec.Name, err)
+		}
+		return s, nil
+	}
+
+	transformsMu.RLock()
+	fn, ok := transforms[spec.Name]
+	transformsMu.RUnlock()
+	if !ok {
+		return step{}, fmt.Errorf("unknown transform %q", spec.Name)
+	}
+	return textStep(spec.Name, fn), nil
+}
+
+// textStep adapts a string Transform to a step; it rejects numbers and dates.
+func textStep(name string, fn func(string) (string, error)) step {
+	return step{scalar: func(value interface{}, _ runContext) (interface{}, error) {
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("transform %q expects text, got %T", name, value)
+		}
+		return fn(s)
+	}}
+}
+
+// buildRegex keeps the first match of Pattern: capture group Group, else the first group
+// if the pattern has one, else the whole match. Values that do not match become empty.
+func buildRegex(spec config.TransformSpec, _ *config.Config) (step, error) {
+	re, err := regexp.Compile(spec.Pattern)
+	if err != nil {
+		return step{}, err
+	}
+	group := spec.Group
+	if group == 0 && re.NumSubexp() > 0 {
+		group = 1
+	}
+	if group > re.NumSubexp() {
+		return step{}, fmt.Errorf("pattern %q has no group %d", spec.Pattern, group)
+	}
+	return textStep(spec.Name, func(value string) (string, error) {
+		m := re.FindStringSubmatch(value)
+		if m == nil {
+			return "", nil
+		}
+		return m[group], nil
+	}), nil
+}
+
+// buildReplace substitutes every match of Pattern with Replacement ($1 expands groups).
+func buildReplace(spec config.TransformSpec, _ *config.Config) (step, error) {
+	re, err := regexp.Compile(spec.Pattern)
+	if err != nil {
+		return step{}, err
+	}
+	return textStep(spec.Name, func(value string) (string, error) {
+		return re.ReplaceAllString(value, spec.Replacement), nil
+	}), nil
+}
+
+// buildTrim removes leading and trailing Chars, or whitespace when Chars is empty.
+func buildTrim(spec config.TransformSpec, _ *config.Config) (step, error) {
+	return textStep(spec.Name, func(value string) (string, error) {
+		if spec.Chars == "" {
+			return strings.TrimSpace(value), nil
+		}
+		return strings.Trim(value, spec.Chars), nil
+	}), nil
+}
+
+// buildSplit splits a value on Separator (default ","), trimming each part.
+func buildSplit(spec config.TransformSpec, _ *config.Config) (step, error) {
+	sep := spec.Separator
+	if sep == "" {
+		sep = ","
+	}
+	return step{scalar: func(value interface{}, _ runContext) (interface{}, error) {
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("transform %q expects text, got %T", spec.Name, value)
+		}
+		var parts []interface{}
+		for _, part := range strings.Split(s, sep) {
+			parts = append(parts, strings.TrimSpace(part))
+		}
+		return parts, nil
+	}}, nil
+}
+
+// buildJoin joins a list of values with Separator (default ", ").
+func buildJoin(spec config.TransformSpec, _ *config.Config) (step, error) {
+	sep := spec.Separator
+	if sep == "" {
+		sep = ", "
+	}
+	return step{list: func(values []interface{}) (interface{}, error) {
+		parts := make([]string, len(values))
+		for i, v := range values {
+			parts[i] = formatValue(v)
+		}
+		return strings.Join(parts, sep), nil
+	}}, nil
+}
+
+// digitGrouping matches a space, apostrophe or no-break space between thousands groups.
+var digitGrouping = regexp.MustCompile(`(\d)[ '\x{00a0}\x{202f}](\d{3})`)
+
+// numberPattern matches the first number once grouping has been removed and the decimal
+// separator normalized to ".".
+var numberPattern = regexp.MustCompile(`[-+]?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?`)
+
+// buildToNumber parses the first number in a value such as "$1,299.00" into a float64.
+// Decimal names the decimal separator (default "."); the other of "." and "," is treated
+// as digit grouping, as are spaces and apostrophes between digits.
+func buildToNumber(spec config.TransformSpec, _ *config.Config) (step, error) {
+	decimal := spec.Decimal
+	if decimal == "" {
+		decimal = "."
+	}
+	if decimal != "." && decimal != "," {
+		return step{}, fmt.Errorf("decimal separator must be \".\" or \",\", got %q", decimal)
+	}
+	return step{scalar: func(value interface{}, _ runContext) (interface{}, error) {
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case string:
+			s := v
+			for digitGrouping.MatchString(s) {
+				s = digitGrouping.ReplaceAllString(s, "$1$2")
+			}
+			if decimal == "," {
+				s = strings.ReplaceAll(s, ".", "")
+				s = strings.ReplaceAll(s, ",", ".")
+			} else {
+				s = strings.ReplaceAll(s, ",", "")
+			}
+			n, err := strconv.ParseFloat(numberPattern.FindString(s), 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse %q as a number", v)
+			}
+			return n, nil
+		}
+		return nil, fmt.Errorf("transform %q expects text, got %T", spec.Name, value)
+	}}, nil
+}
+
+// buildToDate parses a value into a UTC time.Time with a DateParser that tries the spec's
+// Layouts, then DataFormatting.DateLayouts, then the defaults. Relative dates ("3 days ago")
+// are resolved against the record's fetch time. Values that cannot be parsed are kept as
+// their original text rather than failing the record.
+func buildToDate(spec config.TransformSpec, cfg *config.Config) (step, error) {
+	layouts := append(append([]string{}, spec.Layouts...), cfg.DataFormatting.DateLayouts...)
+	dp, err := NewDateParser(layouts, cfg.DataFormatting.Timezone)
+	if err != nil {
+		return step{}, err
+	}
+	return step{scalar: func(value interface{}, ctx runContext) (interface{}, error) {
+		switch v := value.(type) {
+		case time.Time:
+			return v.UTC(), nil
+		case string:
+			if t, err := dp.Parse(v, ctx.fetchedAt); err == nil {
+				return t, nil
+			}
+			return v, nil
+		}
+		return nil, fmt.Errorf("transform %q expects text, got %T", spec.Name, value)
+	}}, nil
+}
+
+// buildDefault replaces an empty or missing value with Value; Process adds fields that
+// are missing from a record when their chain has this step.
+func buildDefault(spec config.TransformSpec, _ *config.Config) (step, error) {
+	return step{
+		keepEmpty: true,
+		scalar: func(value interface{}, _ runContext) (interface{}, error) {
+			if isEmpty(value) {
+				return spec.Value, nil
+			}
+			return value, nil
+		},
+	}, nil
+}
+
+// formatValue renders a chain value as text.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(v)
+}
+
+// isEmpty reports whether a chain value is missing, an empty string or an empty list.
+func isEmpty(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
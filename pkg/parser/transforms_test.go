@@ -0,0 +1,191 @@
+// File: pkg/parser/transforms_test.go
+
+package parser
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+)
+
+// runSpecs compiles specs into a chain and runs it over value.
+func runSpecs(t *testing.T, value interface{}, specs ...config.TransformSpec) (interface{}, error) {
+	t.Helper()
+	var c chain
+	for _, spec := range specs {
+		s, err := compileStep(spec, &config.Config{})
+		if err != nil {
+			t.Fatalf("Unexpected compile error: %v", err)
+		}
+		c.steps = append(c.steps, s)
+	}
+	return c.apply(value, runContext{})
+}
+
+// TestTransformChains verifies each parameterized transform and how steps compose.
+func TestTransformChains(t *testing.T) {
+	cases := []struct {
+		desc      string
+		value     interface{}
+		specs     []config.TransformSpec
+		expected  interface{}
+		expectErr bool
+	}{
+		{
+			desc:     "regex keeps the first group",
+			value:    "Price: $1,299.00 incl. VAT",
+			specs:    []config.TransformSpec{{Name: "regex", Pattern: `\$([0-9.,]+)`}},
+			expected: "1,299.00",
+		},
+		{
+			desc:     "regex with explicit group",
+			value:    "2024-03-01",
+			specs:    []config.TransformSpec{{Name: "regex", Pattern: `(\d+)-(\d+)-(\d+)`, Group: 2}},
+			expected: "03",
+		},
+		{
+			desc:     "regex without groups keeps the whole match; no match drops the value",
+			value:    []string{"SKU ab-12", "none"},
+			specs:    []config.TransformSpec{{Name: "regex", Pattern: `[a-z]+-\d+`}},
+			expected: []string{"ab-12"},
+		},
+		{
+			desc:     "replace expands groups",
+			value:    "Doe, Jane",
+			specs:    []config.TransformSpec{{Name: "replace", Pattern: `^(\w+), (\w+)$`, Replacement: "$2 $1"}},
+			expected: "Jane Doe",
+		},
+		{
+			desc:     "trim chars then upper",
+			value:    "--abc--",
+			specs:    []config.TransformSpec{{Name: "trim", Chars: "-"}, {Name: "upper"}},
+			expected: "ABC",
+		},
+		{
+			desc:     "trim whitespace then lower",
+			value:    "  MiXeD ",
+			specs:    []config.TransformSpec{{Name: "trim"}, {Name: "lower"}},
+			expected: "mixed",
+		},
+		{
+			desc:     "split produces a list",
+			value:    "go, scraping,, web",
+			specs:    []config.TransformSpec{{Name: "split"}},
+			expected: []string{"go", "scraping", "web"},
+		},
+		{
+			desc:     "split then join",
+			value:    "a|b|c",
+			specs:    []config.TransformSpec{{Name: "split", Separator: "|"}, {Name: "join", Separator: "/"}},
+			expected: "a/b/c",
+		},
+		{
+			desc:     "join a multiple field",
+			value:    []string{"x", "y"},
+			specs:    []config.TransformSpec{{Name: "join"}},
+			expected: "x, y",
+		},
+		{
+			desc:     "toNumber with grouping and currency",
+			value:    "$1,299.50",
+			specs:    []config.TransformSpec{{Name: "toNumber"}},
+			expected: 1299.5,
+		},
+		{
+			desc:     "toNumber with decimal comma and space grouping",
+			value:    "1 234 567,89 €",
+			specs:    []config.TransformSpec{{Name: "toNumber", Decimal: ","}},
+			expected: 1234567.89,
+		},
+		{
+			desc:     "toNumber over a list",
+			value:    []string{"4 stars", "-2"},
+			specs:    []config.TransformSpec{{Name: "toNumber"}},
+			expected: []interface{}{4.0, -2.0},
+		},
+		{
+			desc:      "toNumber without digits is an error",
+			value:     "n/a",
+			specs:     []config.TransformSpec{{Name: "toNumber"}},
+			expectErr: true,
+		},
+		{
+			desc:     "toDate with default layouts",
+			value:    "March 3, 2025",
+			specs:    []config.TransformSpec{{Name: "toDate"}},
+			expected: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			desc:     "toDate converts to UTC",
+			value:    "Mon, 03 Mar 2025 10:00:00 +0200",
+			specs:    []config.TransformSpec{{Name: "toDate", Layouts: []string{"RFC3339", "RFC1123Z"}}},
+			expected: time.Date(2025, 3, 3, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			desc:     "toDate with a custom layout",
+			value:    "03/04/2025",
+			specs:    []config.TransformSpec{{Name: "toDate", Layouts: []string{"02/01/2006"}}},
+			expected: time.Date(2025, 4, 3, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			desc:     "toDate keeps the original text when no layout matches",
+			value:    "someday",
+			specs:    []config.TransformSpec{{Name: "toDate"}},
+			expected: "someday",
+		},
+		{
+			desc:      "text transforms reject numbers",
+			value:     "42",
+			specs:     []config.TransformSpec{{Name: "toNumber"}, {Name: "upper"}},
+			expectErr: true,
+		},
+		{
+			desc:     "default replaces a value emptied by earlier steps",
+			value:    "call us",
+			specs:    []config.TransformSpec{{Name: "regex", Pattern: `\d+`}, {Name: "toNumber"}, {Name: "default", Value: "unknown"}},
+			expected: "unknown",
+		},
+		{
+			desc:     "default leaves present values alone",
+			value:    "7",
+			specs:    []config.TransformSpec{{Name: "default", Value: "0"}, {Name: "toNumber"}},
+			expected: 7.0,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := runSpecs(t, tc.value, tc.specs...)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("Expected error, got %#v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Expected %#v, got %#v", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestCompileStepErrors verifies that invalid transform parameters are rejected when compiling.
+func TestCompileStepErrors(t *testing.T) {
+	cases := []config.TransformSpec{
+		{Name: "missing"},
+		{Name: "regex", Pattern: "("},
+		{Name: "regex", Pattern: `(\d)`, Group: 2},
+		{Name: "replace", Pattern: "["},
+		{Name: "toNumber", Decimal: ";"},
+	}
+	for _, spec := range cases {
+		if _, err := compileStep(spec, &config.Config{}); err == nil {
+			t.Errorf("Expected error for %+v", spec)
+		}
+	}
+}
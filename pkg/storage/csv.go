@@ -0,0 +1,287 @@
+// File: pkg/storage/csv.go
+
+package storage
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+	"github.com/heinrichb/scrapey-cli/pkg/parser"
+)
+
+/*
+writeCSV writes records to w as CSV, one row per record, or one per object for records
+with lists of objects.
+
+Parameters:
+  - w: The destination.
+  - records: The records to write.
+  - cfg: Supplies the configured fields (column order) and Storage.CSV settings.
+
+Returns:
+  - An error if a row could not be written.
+
+Notes:
+  - Columns are "url" and "fetchedAt", then the configured fields in the order of
+    config.FieldNames. Fields with nested Fields rules become one column per sub-field
+    ("price.amount"); any other keys found in the records follow their parent field or,
+    if unconfigured, come last in sorted order. Every configured column is present even
+    when no record has a value for it.
+  - Lists of plain values are joined with Storage.CSV.ListSeparator. A field holding a
+    list of objects, such as table rows, becomes one "field.key" column per key, and its
+    record takes one row per object, with the record's other columns repeated on each.
+    Several such fields on one record are paired up by position.
+  - The header row is omitted when Storage.CSV.SkipHeader is set.
+*/
+func writeCSV(w io.Writer, records []*parser.Record, cfg *config.Config) error {
+	opts := cfg.Storage.CSV
+	separator := opts.ListSeparator
+	if separator == "" {
+		separator = "; "
+	}
+
+	rows := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		rows[i] = flattenFields(record.Fields, separator)
+	}
+	columns, objects := objectColumns(rows, csvColumns(rows, cfg), separator)
+
+	cw := csv.NewWriter(w)
+	if opts.Delimiter != "" {
+		cw.Comma, _ = utf8.DecodeRuneInString(opts.Delimiter)
+	}
+	if !opts.SkipHeader {
+		if err := cw.Write(append([]string{"url", "fetchedAt"}, columns...)); err != nil {
+			return fmt.Errorf("failed to write CSV: %v", err)
+		}
+	}
+	for i, record := range records {
+		fetchedAt := ""
+		if !record.FetchedAt.IsZero() {
+			fetchedAt = record.FetchedAt.UTC().Format(time.RFC3339)
+		}
+		for n := 0; n == 0 || n < len(objects[i]); n++ {
+			line := []string{record.URL, fetchedAt}
+			for _, column := range columns {
+				value, ok := rows[i][column]
+				if n < len(objects[i]) {
+					if v, sub := objects[i][n][column]; sub {
+						value, ok = v, true
+					}
+				}
+				if !ok {
+					value = nil
+				}
+				line = append(line, cellText(value))
+			}
+			if err := cw.Write(line); err != nil {
+				return fmt.Errorf("failed to write CSV: %v", err)
+			}
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %v", err)
+	}
+	return nil
+}
+
+// csvColumns orders the field columns: configured columns, each followed by any extra keys
+// nested under it, then the remaining keys of rows.
+func csvColumns(rows []map[string]interface{}, cfg *config.Config) []string {
+	found := make(map[string]bool)
+	for _, row := range rows {
+		for key := range row {
+			found[key] = true
+		}
+	}
+	extra := make([]string, 0, len(found))
+	for key := range found {
+		extra = append(extra, key)
+	}
+	sort.Strings(extra)
+
+	rules := columnRules(cfg)
+	var columns []string
+	used := make(map[string]bool)
+	add := func(column string) {
+		if !used[column] {
+			used[column] = true
+			columns = append(columns, column)
+		}
+	}
+	for _, name := range cfg.FieldNames() {
+		for _, column := range ruleColumns(name, rules[name]) {
+			add(column)
+		}
+		for _, key := range extra {
+			if strings.HasPrefix(key, name+".") {
+				add(key)
+			}
+		}
+	}
+	for _, key := range extra {
+		add(key)
+	}
+	return columns
+}
+
+/*
+objectColumns replaces the columns that hold lists of objects with a column per key.
+
+Parameters:
+  - rows: The flattened fields of each record.
+  - columns: The columns from csvColumns.
+  - separator: Joins the items of lists of plain values inside the objects.
+
+Returns:
+  - The columns, with each object column replaced by its "column.key" columns in order
+    of first appearance.
+  - Per record, its extra rows: row n merges the flattened n-th object of every object
+    column. A record without objects has none.
+*/
+func objectColumns(rows []map[string]interface{}, columns []string, separator string) ([]string, [][]map[string]interface{}) {
+	extra := make([][]map[string]interface{}, len(rows))
+	var expanded []string
+	for _, column := range columns {
+		if !holdsObjects(rows, column) {
+			expanded = append(expanded, column)
+			continue
+		}
+		var keys []string
+		for i, row := range rows {
+			for n, object := range objectList(row[column]) {
+				flat := make(map[string]interface{})
+				for key, value := range flattenFields(object, separator) {
+					flat[column+"."+key] = value
+				}
+				for _, key := range orderedKeys(flat, nil) {
+					if !containsString(keys, key) {
+						keys = append(keys, key)
+					}
+				}
+				if n == len(extra[i]) {
+					extra[i] = append(extra[i], make(map[string]interface{}))
+				}
+				for key, value := range flat {
+					extra[i][n][key] = value
+				}
+			}
+			delete(row, column)
+		}
+		expanded = append(expanded, keys...)
+	}
+	return expanded, extra
+}
+
+// columnRules returns the rules of every configured field, page and item fields alike.
+func columnRules(cfg *config.Config) map[string]config.FieldRule {
+	rules := cfg.FieldRules()
+	if cfg.ParseRules.Items != nil {
+		for name, rule := range cfg.ParseRules.Items.Fields {
+			if _, ok := rules[name]; !ok {
+				rules[name] = rule
+			}
+		}
+	}
+	return rules
+}
+
+// ruleColumns returns the columns a rule produces: one per leaf of its nested Fields, or
+// the rule's own name.
+func ruleColumns(name string, rule config.FieldRule) []string {
+	if len(rule.Fields) == 0 || rule.Type == config.RuleTable {
+		return []string{name}
+	}
+	subNames := make([]string, 0, len(rule.Fields))
+	for sub := range rule.Fields {
+		subNames = append(subNames, sub)
+	}
+	sort.Strings(subNames)
+	var columns []string
+	for _, sub := range subNames {
+		columns = append(columns, ruleColumns(name+"."+sub, rule.Fields[sub])...)
+	}
+	return columns
+}
+
+/*
+flattenFields flattens a record's fields into one value per column.
+
+Parameters:
+  - fields: The record's fields.
+  - separator: Joins the items of lists of plain values.
+
+Returns:
+  - A map from column name to value. Objects are expanded into "name.sub" columns and
+    lists of plain values are joined into a string; other values, including lists that
+    hold objects, are kept as they are.
+*/
+func flattenFields(fields map[string]interface{}, separator string) map[string]interface{} {
+	row := make(map[string]interface{})
+	for name, value := range fields {
+		flattenValue(name, value, separator, row)
+	}
+	return row
+}
+
+// flattenValue stores value in row under key, expanding objects into "key.sub" entries.
+func flattenValue(key string, value interface{}, separator string, row map[string]interface{}) {
+	switch v := value.(type) {
+	case nil:
+	case map[string]interface{}:
+		for sub, x := range v {
+			flattenValue(key+"."+sub, x, separator, row)
+		}
+	case []string:
+		row[key] = strings.Join(v, separator)
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, x := range v {
+			switch x.(type) {
+			case map[string]interface{}, []interface{}, []map[string]interface{}, []string:
+				row[key] = v
+				return
+			}
+			parts = append(parts, cellText(x))
+		}
+		row[key] = strings.Join(parts, separator)
+	default:
+		row[key] = v
+	}
+}
+
+// cellText formats a value for a single cell, encoding any remaining lists as JSON.
+func cellText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case []interface{}, []map[string]interface{}:
+		return jsonCell(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// jsonCell encodes a composite value as compact JSON.
+func jsonCell(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,109 @@
+// File: pkg/storage/csv_test.go
+
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+	"github.com/heinrichb/scrapey-cli/pkg/parser"
+)
+
+// TestSaveDataCSV verifies column order, quoting, list flattening, a row per table row and
+// the CSV options.
+func TestSaveDataCSV(t *testing.T) {
+	records := []*parser.Record{
+		{
+			URL: "https://example.com/a",
+			Fields: map[string]interface{}{
+				"title":  "Hello, \"world\"",
+				"tags":   []interface{}{"go", "csv"},
+				"price":  map[string]interface{}{"amount": 9.5, "currency": "EUR"},
+				"specs":  []map[string]interface{}{{"CPU": "M3"}, {"CPU": "M4", "RAM": "16GB"}},
+				"body":   "line one\nline two",
+				"extra":  true,
+				"images": []string{"a.png", "b.png"},
+			},
+			FetchedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			URL:    "https://example.com/b",
+			Fields: map[string]interface{}{"title": "Second", "price": map[string]interface{}{"amount": 3.0, "note": "sale"}},
+		},
+	}
+
+	cases := []struct {
+		desc      string
+		delimiter string
+		separator string
+		skip      bool
+		expected  string
+	}{
+		{
+			desc: "Defaults",
+			expected: "url,fetchedAt,title,body,images,price.amount,price.currency,price.note,specs.CPU,specs.RAM,tags,extra\n" +
+				"https://example.com/a,2025-03-01T12:00:00Z,\"Hello, \"\"world\"\"\",\"line one\nline two\",a.png; b.png,9.5,EUR,,M3,,go; csv,true\n" +
+				"https://example.com/a,2025-03-01T12:00:00Z,\"Hello, \"\"world\"\"\",\"line one\nline two\",a.png; b.png,9.5,EUR,,M4,16GB,go; csv,true\n" +
+				"https://example.com/b,,Second,,,3,,sale,,,,\n",
+		},
+		{
+			desc:      "Delimiter, list separator and no header",
+			delimiter: "\t",
+			separator: "|",
+			skip:      true,
+			expected: "https://example.com/a\t2025-03-01T12:00:00Z\t\"Hello, \"\"world\"\"\"\t\"line one\nline two\"\ta.png|b.png\t9.5\tEUR\t\tM3\t\tgo|csv\ttrue\n" +
+				"https://example.com/a\t2025-03-01T12:00:00Z\t\"Hello, \"\"world\"\"\"\t\"line one\nline two\"\ta.png|b.png\t9.5\tEUR\t\tM4\t16GB\tgo|csv\ttrue\n" +
+				"https://example.com/b\t\tSecond\t\t\t3\t\tsale\t\t\t\t\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			dir := t.TempDir()
+			cfg := testConfig(dir)
+			cfg.ParseRules.Title = "h1"
+			cfg.ParseRules.Fields = map[string]config.FieldRule{
+				"body":   {Selector: ".body"},
+				"images": {Selector: "img@src", Multiple: true},
+				"price":  {Selector: ".price", Fields: map[string]config.FieldRule{"amount": {Selector: ".amount"}, "currency": {Selector: ".currency"}}},
+				"specs":  {Selector: "table", Type: config.RuleTable},
+				"tags":   {Selector: ".tag", Multiple: true},
+			}
+			cfg.Storage.CSV.Delimiter = tc.delimiter
+			cfg.Storage.CSV.ListSeparator = tc.separator
+			cfg.Storage.CSV.SkipHeader = tc.skip
+
+			path, err := SaveData(records, cfg, CSV)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if path != filepath.Join(dir, "results.csv") {
+				t.Errorf("Unexpected path %s", path)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			if string(data) != tc.expected {
+				t.Errorf("Expected:\n%q\ngot:\n%q", tc.expected, data)
+			}
+		})
+	}
+}
+
+// TestSaveDataCSVEmpty verifies that configured columns appear even without records.
+func TestSaveDataCSVEmpty(t *testing.T) {
+	cfg := testConfig(t.TempDir())
+	cfg.ParseRules.Items = &config.FieldRule{Selector: ".product", Fields: map[string]config.FieldRule{"name": {Selector: "h2"}}}
+	path, err := SaveData(nil, cfg, CSV)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "url,fetchedAt,name\n" {
+		t.Errorf("Expected only the header, got %q", data)
+	}
+}
@@ -0,0 +1,460 @@
+// File: pkg/storage/excel.go
+
+package storage
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+	"github.com/heinrichb/scrapey-cli/pkg/parser"
+	"github.com/xuri/excelize/v2"
+)
+
+/*
+Limits applied to Excel output.
+
+  - minColumnWidth, maxColumnWidth: Bounds for auto-sized column widths, in characters.
+  - maxSheetLinks: Hyperlinks per sheet; further URLs are written as plain text.
+*/
+const (
+	minColumnWidth = 8
+	maxColumnWidth = 60
+	maxSheetLinks  = excelize.TotalSheetHyperlinks
+)
+
+/*
+excelStyles holds the cell styles registered in a workbook.
+
+Fields:
+  - header: Bold text for the header row.
+  - date: A date and time number format for time values.
+  - link: Blue, underlined text for hyperlinks.
+*/
+type excelStyles struct {
+	header int
+	date   int
+	link   int
+}
+
+/*
+writeExcel writes records to w as an .xlsx workbook.
+
+Parameters:
+  - w: The destination.
+  - records: The records to write.
+  - cfg: Supplies the routes (sheets), the configured fields (column order) and
+    Storage.CSV.ListSeparator for lists of plain values.
+
+Returns:
+  - An error if the workbook could not be built or written.
+
+Notes:
+  - Records go to one sheet per URL.Routes entry, chosen by the longest route whose path
+    is a prefix of the record's URL ("/blog/*" collects "/blog/..." pages); records under
+    no route go to an "Other" sheet. Sheets are named after the route, and a crawl
+    without records yields a single "Records" sheet with just the header.
+  - Columns are ordered as in CSV output (see writeCSV). Fields holding lists of objects,
+    such as table rows, get a sheet of their own, named after the field, with one row per
+    object and a "url" column pointing back at the record's page.
+  - The header row is bold and columns are sized to their content.
+  - Numbers, booleans and dates produced by the parser are written as typed cells, and
+    http(s) URLs as hyperlinks.
+*/
+func writeExcel(w io.Writer, records []*parser.Record, cfg *config.Config) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	styles, err := newExcelStyles(f)
+	if err != nil {
+		return err
+	}
+	separator := cfg.Storage.CSV.ListSeparator
+	if separator == "" {
+		separator = "; "
+	}
+
+	// Group records by route, keeping the order of URL.Routes.
+	routes := routeSheets(cfg)
+	groups := make(map[string][]int)
+	var order []string
+	for i, record := range records {
+		name := routeSheet(record.URL, routes)
+		if _, ok := groups[name]; !ok {
+			order = append(order, name)
+		}
+		groups[name] = append(groups[name], i)
+	}
+	sort.SliceStable(order, func(i, j int) bool { return sheetRank(order[i], routes) < sheetRank(order[j], routes) })
+
+	sheets := newSheetNamer()
+	if len(records) == 0 {
+		columns := csvColumns(nil, cfg)
+		return finishWorkbook(f, w, sheets, []excelSheet{{name: "Records", header: append([]string{"url", "fetchedAt"}, columns...)}}, styles)
+	}
+
+	var out []excelSheet
+	nested := make(map[string]*excelSheet)
+	var nestedOrder []string
+	for _, name := range order {
+		indexes := groups[name]
+		rows := make([]map[string]interface{}, len(indexes))
+		for i, index := range indexes {
+			rows[i] = flattenFields(records[index].Fields, separator)
+		}
+
+		sheet := excelSheet{name: name}
+		var columns []string
+		for _, column := range csvColumns(rows, cfg) {
+			if !holdsObjects(rows, column) {
+				columns = append(columns, column)
+				continue
+			}
+			if nested[column] == nil {
+				nested[column] = &excelSheet{name: column}
+				nestedOrder = append(nestedOrder, column)
+			}
+			for i, index := range indexes {
+				addObjectRows(nested[column], records[index].URL, rows[i][column], separator)
+			}
+		}
+		sheet.header = append([]string{"url", "fetchedAt"}, columns...)
+		for i, index := range indexes {
+			line := []interface{}{records[index].URL, nil}
+			if t := records[index].FetchedAt; !t.IsZero() {
+				line[1] = t.UTC()
+			}
+			for _, column := range columns {
+				line = append(line, rows[i][column])
+			}
+			sheet.rows = append(sheet.rows, line)
+		}
+		out = append(out, sheet)
+	}
+	for _, column := range nestedOrder {
+		out = append(out, *nested[column])
+	}
+	return finishWorkbook(f, w, sheets, out, styles)
+}
+
+/*
+excelSheet is the content of one worksheet.
+
+Fields:
+  - name: The preferred sheet name; it is shortened and made unique when written.
+  - header: The column headers.
+  - rows: The cell values of each row, in header order.
+*/
+type excelSheet struct {
+	name   string
+	header []string
+	rows   [][]interface{}
+}
+
+// finishWorkbook writes sheets to f, removes the default sheet and saves the workbook to w.
+func finishWorkbook(f *excelize.File, w io.Writer, sheets *sheetNamer, content []excelSheet, styles excelStyles) error {
+	defaultSheet := f.GetSheetName(0)
+	for _, sheet := range content {
+		name := sheets.name(sheet.name)
+		if _, err := f.NewSheet(name); err != nil {
+			return fmt.Errorf("failed to add sheet %s: %v", name, err)
+		}
+		if err := writeSheet(f, name, sheet, styles); err != nil {
+			return err
+		}
+	}
+	if err := f.DeleteSheet(defaultSheet); err != nil {
+		return fmt.Errorf("failed to build workbook: %v", err)
+	}
+	f.SetActiveSheet(0)
+	if err := f.Write(w); err != nil {
+		return fmt.Errorf("failed to write workbook: %v", err)
+	}
+	return nil
+}
+
+// writeSheet fills one worksheet: a bold header row, typed cells and sized columns.
+func writeSheet(f *excelize.File, name string, sheet excelSheet, styles excelStyles) error {
+	widths := make([]int, len(sheet.header))
+	for col, title := range sheet.header {
+		cell, _ := excelize.CoordinatesToCellName(col+1, 1)
+		if err := f.SetCellValue(name, cell, title); err != nil {
+			return fmt.Errorf("failed to write sheet %s: %v", name, err)
+		}
+		widths[col] = utf8.RuneCountInString(title)
+	}
+	last, _ := excelize.CoordinatesToCellName(max(len(sheet.header), 1), 1)
+	if err := f.SetCellStyle(name, "A1", last, styles.header); err != nil {
+		return fmt.Errorf("failed to write sheet %s: %v", name, err)
+	}
+
+	links := 0
+	for r, row := range sheet.rows {
+		for col, value := range row {
+			cell, _ := excelize.CoordinatesToCellName(col+1, r+2)
+			width, err := writeCell(f, name, cell, value, styles, &links)
+			if err != nil {
+				return fmt.Errorf("failed to write sheet %s: %v", name, err)
+			}
+			widths[col] = max(widths[col], width)
+		}
+	}
+
+	for col, width := range widths {
+		letter, _ := excelize.ColumnNumberToName(col + 1)
+		width = min(max(width+2, minColumnWidth), maxColumnWidth)
+		if err := f.SetColWidth(name, letter, letter, float64(width)); err != nil {
+			return fmt.Errorf("failed to write sheet %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// writeCell stores value in cell with a type matching the value and returns its display width.
+func writeCell(f *excelize.File, sheet, cell string, value interface{}, styles excelStyles, links *int) (int, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case float64, float32, int, int64, bool:
+		text := cellText(v)
+		return len(text), f.SetCellValue(sheet, cell, v)
+	case time.Time:
+		if err := f.SetCellValue(sheet, cell, v.UTC()); err != nil {
+			return 0, err
+		}
+		return len("2006-01-02 15:04:05"), f.SetCellStyle(sheet, cell, cell, styles.date)
+	case string:
+		if err := f.SetCellValue(sheet, cell, v); err != nil {
+			return 0, err
+		}
+		if isWebURL(v) && *links < maxSheetLinks {
+			*links++
+			if err := f.SetCellHyperLink(sheet, cell, v, "External"); err != nil {
+				return 0, err
+			}
+			if err := f.SetCellStyle(sheet, cell, cell, styles.link); err != nil {
+				return 0, err
+			}
+		}
+		return longestLine(v), nil
+	default:
+		text := cellText(v)
+		return longestLine(text), f.SetCellValue(sheet, cell, text)
+	}
+}
+
+// newExcelStyles registers the header, date and hyperlink styles in f.
+func newExcelStyles(f *excelize.File) (excelStyles, error) {
+	var s excelStyles
+	var err error
+	if s.header, err = f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}}); err != nil {
+		return s, fmt.Errorf("failed to create styles: %v", err)
+	}
+	dateFormat := "yyyy-mm-dd hh:mm:ss"
+	if s.date, err = f.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat}); err != nil {
+		return s, fmt.Errorf("failed to create styles: %v", err)
+	}
+	if s.link, err = f.NewStyle(&excelize.Style{Font: &excelize.Font{Color: "0563C1", Underline: "single"}}); err != nil {
+		return s, fmt.Errorf("failed to create styles: %v", err)
+	}
+	return s, nil
+}
+
+// holdsObjects reports whether any row's value for column is a list of objects.
+func holdsObjects(rows []map[string]interface{}, column string) bool {
+	for _, row := range rows {
+		switch row[column].(type) {
+		case []map[string]interface{}, []interface{}:
+			return true
+		}
+	}
+	return false
+}
+
+// addObjectRows appends one row per object in value to sheet, extending its header with
+// any new keys; pageURL fills the leading "url" column.
+func addObjectRows(sheet *excelSheet, pageURL string, value interface{}, separator string) {
+	if len(sheet.header) == 0 {
+		sheet.header = []string{"url"}
+	}
+	for _, object := range objectList(value) {
+		flat := flattenFields(object, separator)
+		for _, key := range orderedKeys(flat, nil) {
+			if !containsString(sheet.header, key) {
+				sheet.header = append(sheet.header, key)
+			}
+		}
+		line := make([]interface{}, len(sheet.header))
+		line[0] = pageURL
+		for i, key := range sheet.header[1:] {
+			line[i+1] = flat[key]
+		}
+		sheet.rows = append(sheet.rows, line)
+	}
+}
+
+// objectList returns the objects of a list held by a field; plain items become objects
+// with a single "value" key.
+func objectList(value interface{}) []map[string]interface{} {
+	var objects []map[string]interface{}
+	switch v := value.(type) {
+	case []map[string]interface{}:
+		objects = v
+	case []interface{}:
+		for _, x := range v {
+			if m, ok := x.(map[string]interface{}); ok {
+				objects = append(objects, m)
+			} else if x != nil {
+				objects = append(objects, map[string]interface{}{"value": x})
+			}
+		}
+	}
+	return objects
+}
+
+/*
+routeSheetEntry pairs a route's path prefix with the sheet its records go to.
+
+Fields:
+  - prefix: The route's path, up to its first wildcard.
+  - name: The sheet name derived from the route.
+*/
+type routeSheetEntry struct {
+	prefix string
+	name   string
+}
+
+// routeSheets derives a sheet for each entry of URL.Routes.
+func routeSheets(cfg *config.Config) []routeSheetEntry {
+	var entries []routeSheetEntry
+	for _, route := range cfg.URL.Routes {
+		route = strings.TrimSpace(route)
+		if route == "" {
+			continue
+		}
+		prefix := route
+		if i := strings.IndexAny(prefix, "*?#"); i >= 0 {
+			prefix = prefix[:i]
+		}
+		if u, err := url.Parse(prefix); err == nil {
+			prefix = u.Path
+		}
+		if !strings.HasPrefix(prefix, "/") {
+			prefix = "/" + prefix
+		}
+		name := strings.Trim(strings.ReplaceAll(route, "*", ""), "/")
+		if name == "" {
+			name = "Home"
+		}
+		entries = append(entries, routeSheetEntry{prefix: prefix, name: name})
+	}
+	return entries
+}
+
+// routeSheet returns the sheet for pageURL: the longest route prefix of its path, or "Other".
+func routeSheet(pageURL string, routes []routeSheetEntry) string {
+	path := "/"
+	if u, err := url.Parse(pageURL); err == nil && u.Path != "" {
+		path = u.Path
+	}
+	best, name := -1, "Other"
+	for _, r := range routes {
+		trimmed := strings.TrimSuffix(r.prefix, "/")
+		if (path == r.prefix || path == trimmed || strings.HasPrefix(path, trimmed+"/")) && len(r.prefix) > best {
+			best, name = len(r.prefix), r.name
+		}
+	}
+	return name
+}
+
+// sheetRank orders sheets by the position of their route, with "Other" last.
+func sheetRank(name string, routes []routeSheetEntry) int {
+	for i, r := range routes {
+		if r.name == name {
+			return i
+		}
+	}
+	return len(routes)
+}
+
+/*
+sheetNamer turns preferred names into valid, unique sheet names.
+
+Notes:
+  - Characters Excel forbids in sheet names (: \ / ? * [ ]) become "-", names are cut to
+    31 characters, and repeated names (compared case-insensitively) get a " (2)" suffix.
+*/
+type sheetNamer struct {
+	used map[string]bool
+}
+
+// newSheetNamer returns a sheetNamer with no names taken.
+func newSheetNamer() *sheetNamer {
+	return &sheetNamer{used: make(map[string]bool)}
+}
+
+// name returns a valid sheet name for preferred that has not been returned before.
+func (n *sheetNamer) name(preferred string) string {
+	base := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`:\/?*[]`, r) {
+			return '-'
+		}
+		return r
+	}, preferred)
+	base = strings.Trim(strings.TrimSpace(base), "'")
+	if base == "" {
+		base = "Sheet"
+	}
+	for i := 1; ; i++ {
+		suffix := ""
+		if i > 1 {
+			suffix = fmt.Sprintf(" (%d)", i)
+		}
+		name := truncateRunes(base, excelize.MaxSheetNameLength-utf8.RuneCountInString(suffix)) + suffix
+		if !n.used[strings.ToLower(name)] {
+			n.used[strings.ToLower(name)] = true
+			return name
+		}
+	}
+}
+
+// truncateRunes cuts s to at most n runes.
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// longestLine returns the length in runes of the longest line of s.
+func longestLine(s string) int {
+	longest := 0
+	for _, line := range strings.Split(s, "\n") {
+		longest = max(longest, utf8.RuneCountInString(line))
+	}
+	return longest
+}
+
+// isWebURL reports whether s is an absolute http(s) URL.
+func isWebURL(s string) bool {
+	if (!strings.HasPrefix(s, "http://") && !strings.HasPrefix(s, "https://")) || strings.ContainsAny(s, " \n\t") {
+		return false
+	}
+	u, err := url.Parse(s)
+	return err == nil && u.Host != ""
+}
+
+// containsString reports whether list contains s.
+func containsString(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+// File: pkg/storage/excel_test.go
+
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+	"github.com/heinrichb/scrapey-cli/pkg/parser"
+	"github.com/xuri/excelize/v2"
+)
+
+// TestSaveDataExcel verifies sheets per route, typed cells, hyperlinks, styles and the
+// separate sheet for table rows.
+func TestSaveDataExcel(t *testing.T) {
+	fetched := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	records := []*parser.Record{
+		{
+			URL: "https://example.com/blog/one",
+			Fields: map[string]interface{}{
+				"title":     "First",
+				"price":     9.5,
+				"inStock":   true,
+				"published": time.Date(2024, 12, 24, 8, 30, 0, 0, time.UTC),
+				"source":    "https://source.example/a",
+				"specs":     []map[string]interface{}{{"CPU": "M3", "RAM": "16 GB"}},
+			},
+			FetchedAt: fetched,
+		},
+		{
+			URL:       "https://example.com/shop/item",
+			Fields:    map[string]interface{}{"title": "Item", "tags": []interface{}{"a", "b"}},
+			FetchedAt: fetched,
+		},
+		{
+			URL:    "https://example.com/elsewhere",
+			Fields: map[string]interface{}{"title": "Stray"},
+		},
+	}
+
+	cfg := testConfig(t.TempDir())
+	cfg.URL.Routes = []string{"/shop", "/blog/*"}
+	cfg.ParseRules.Title = "h1"
+	cfg.ParseRules.Fields = map[string]config.FieldRule{
+		"price": {Selector: ".price"},
+		"specs": {Selector: "table", Type: config.RuleTable},
+	}
+
+	path, err := SaveData(records, cfg, Excel)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		t.Fatalf("Failed to open workbook: %v", err)
+	}
+	defer f.Close()
+
+	if sheets := f.GetSheetList(); !reflect.DeepEqual(sheets, []string{"shop", "blog", "Other", "specs"}) {
+		t.Errorf("Unexpected sheets %v", sheets)
+	}
+
+	rows, _ := f.GetRows("blog")
+	expectedHeader := []string{"url", "fetchedAt", "title", "price", "inStock", "published", "source"}
+	if len(rows) != 2 || !reflect.DeepEqual(rows[0], expectedHeader) {
+		t.Fatalf("Unexpected blog rows %v", rows)
+	}
+
+	cases := []struct {
+		cell     string
+		typ      excelize.CellType
+		expected string
+	}{
+		{"C2", excelize.CellTypeSharedString, "First"},
+		{"D2", excelize.CellTypeUnset, "9.5"},
+		{"E2", excelize.CellTypeBool, "TRUE"},
+		{"F2", excelize.CellTypeUnset, "2024-12-24 08:30:00"},
+		{"B2", excelize.CellTypeUnset, "2025-03-01 12:00:00"},
+	}
+	for _, tc := range cases {
+		typ, _ := f.GetCellType("blog", tc.cell)
+		value, _ := f.GetCellValue("blog", tc.cell)
+		if typ != tc.typ || value != tc.expected {
+			t.Errorf("%s: expected %q (type %v), got %q (type %v)", tc.cell, tc.expected, tc.typ, value, typ)
+		}
+	}
+
+	for _, cell := range []string{"A2", "G2"} {
+		ok, link, _ := f.GetCellHyperLink("blog", cell)
+		value, _ := f.GetCellValue("blog", cell)
+		if !ok || link != value {
+			t.Errorf("%s: expected a hyperlink to %q, got %v %q", cell, value, ok, link)
+		}
+	}
+	if ok, _, _ := f.GetCellHyperLink("blog", "C2"); ok {
+		t.Error("Expected plain text not to be a hyperlink")
+	}
+
+	styleID, _ := f.GetCellStyle("blog", "C1")
+	style, _ := f.GetStyle(styleID)
+	if style == nil || style.Font == nil || !style.Font.Bold {
+		t.Error("Expected a bold header row")
+	}
+	if width, _ := f.GetColWidth("blog", "A"); width != float64(len("https://example.com/blog/one")+2) {
+		t.Errorf("Expected column A to fit its content, got width %v", width)
+	}
+	if width, _ := f.GetColWidth("blog", "D"); width != minColumnWidth {
+		t.Errorf("Expected narrow columns to get the minimum width, got %v", width)
+	}
+
+	shop, _ := f.GetRows("shop")
+	if len(shop) != 2 || shop[1][len(shop[1])-1] != "a; b" {
+		t.Errorf("Expected joined list values on the shop sheet, got %v", shop)
+	}
+	specs, _ := f.GetRows("specs")
+	if !reflect.DeepEqual(specs, [][]string{{"url", "CPU", "RAM"}, {"https://example.com/blog/one", "M3", "16 GB"}}) {
+		t.Errorf("Unexpected specs rows %v", specs)
+	}
+}
+
+// TestSheetNamer verifies sheet name sanitizing, truncation and de-duplication.
+func TestSheetNamer(t *testing.T) {
+	n := newSheetNamer()
+	cases := []struct {
+		preferred string
+		expected  string
+	}{
+		{"blog", "blog"},
+		{"Blog", "Blog (2)"},
+		{"a/b:c?[d]", "a-b-c--d-"},
+		{"", "Sheet"},
+		{"a very long route name that exceeds the limit", "a very long route name that exc"},
+		{"a very long route name that exceeds the limit", "a very long route name that (2)"},
+	}
+	for _, tc := range cases {
+		if got := n.name(tc.preferred); got != tc.expected {
+			t.Errorf("name(%q): expected %q, got %q", tc.preferred, tc.expected, got)
+		}
+	}
+}
+
+// TestSaveDataExcelEmpty verifies that a crawl without records yields a header-only sheet.
+func TestSaveDataExcelEmpty(t *testing.T) {
+	cfg := testConfig(t.TempDir())
+	cfg.ParseRules.Title = "h1"
+	path, err := SaveData(nil, cfg, Excel)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		t.Fatalf("Failed to open workbook: %v", err)
+	}
+	defer f.Close()
+	rows, _ := f.GetRows("Records")
+	if !reflect.DeepEqual(f.GetSheetList(), []string{"Records"}) || !reflect.DeepEqual(rows, [][]string{{"url", "fetchedAt", "title"}}) {
+		t.Errorf("Unexpected workbook %v: %v", f.GetSheetList(), rows)
+	}
+}
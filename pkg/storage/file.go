@@ -0,0 +1,96 @@
+// File: pkg/storage/file.go
+
+package storage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+/*
+writeFileAtomic creates or replaces the file at path with the output of write.
+
+Parameters:
+  - path: The destination file; missing parent directories are created.
+  - write: Produces the file's content.
+
+Returns:
+  - An error if the directory, the temporary file or the rename failed, or if write failed.
+
+Notes:
+  - The content goes to a temporary file in the same directory, is synced to disk and then
+    renamed over path, so readers see either the old file or the complete new one.
+  - The temporary file is removed on any failure.
+*/
+func writeFileAtomic(path string, write func(io.Writer) error) error {
+	f, err := createAtomic(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.discard()
+		return err
+	}
+	return f.commit()
+}
+
+/*
+atomicFile is a temporary file that replaces its destination when committed.
+
+Fields:
+  - Writer: Buffers writes to tmp.
+  - path: The destination file.
+  - tmp: The temporary file beside path.
+*/
+type atomicFile struct {
+	*bufio.Writer
+	path string
+	tmp  *os.File
+}
+
+// createAtomic creates the temporary file for path, creating directories as needed.
+func createAtomic(path string) (*atomicFile, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %v", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	return &atomicFile{Writer: bufio.NewWriter(tmp), path: path, tmp: tmp}, nil
+}
+
+// commit flushes, syncs and closes the temporary file and renames it over the
+// destination. The temporary file is removed on failure.
+func (f *atomicFile) commit() error {
+	err := f.Flush()
+	if err == nil {
+		err = f.tmp.Chmod(0644)
+	}
+	if err == nil {
+		err = f.tmp.Sync()
+	}
+	if err != nil {
+		f.discard()
+		return fmt.Errorf("failed to write %s: %v", f.path, err)
+	}
+	if err := f.tmp.Close(); err != nil {
+		os.Remove(f.tmp.Name())
+		return fmt.Errorf("failed to write %s: %v", f.path, err)
+	}
+	if err := os.Rename(f.tmp.Name(), f.path); err != nil {
+		os.Remove(f.tmp.Name())
+		return fmt.Errorf("failed to replace %s: %v", f.path, err)
+	}
+	return nil
+}
+
+// discard closes and removes the temporary file, leaving the destination untouched.
+func (f *atomicFile) discard() {
+	f.tmp.Close()
+	os.Remove(f.tmp.Name())
+}
@@ -0,0 +1,163 @@
+// File: pkg/storage/jsonl.go
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+	"github.com/heinrichb/scrapey-cli/pkg/parser"
+)
+
+// syncInterval is the longest a JSONLSink lets written lines sit in the OS cache before
+// syncing them to disk.
+const syncInterval = time.Second
+
+/*
+JSONLSink streams records to a newline-delimited JSON file while a crawl runs.
+
+Fields:
+  - file: The open output file.
+  - lastSync: When the file was last synced to disk.
+  - started: Whether the previous contents have been truncated yet.
+
+Usage:
+
+	sink, err := storage.OpenJSONL(cfg)
+	if err != nil {
+	    // Handle error
+	}
+	// For each record as its page completes:
+	err = sink.Write(record)
+	// After the crawl, or sink.Discard() if it failed:
+	err = sink.Close()
+
+Notes:
+  - Each record is written as one line with a single write, so the file always ends in
+    complete lines (except after a crash during that write) and can be followed with
+    `tail -f` or `jq` while the crawl is running.
+  - Lines are handed to the OS immediately and synced to disk at least once per
+    syncInterval, and on Close.
+  - The previous run's file is truncated by the first Write, or by Close if nothing was
+    written, so a crawl that fails before saving anything leaves it untouched.
+  - A JSONLSink is not safe for concurrent use; crawler.Crawl never calls its visit
+    function concurrently.
+*/
+type JSONLSink struct {
+	file     *os.File
+	lastSync time.Time
+	started  bool
+}
+
+/*
+OpenJSONL creates "<savePath>/<fileName>.jsonl" for streaming, creating directories as needed.
+
+Parameters:
+  - cfg: The loaded configuration; Storage.SavePath and Storage.FileName name the output.
+
+Returns:
+  - The sink, or an error if the file could not be created.
+
+Notes:
+  - An existing file is kept until the first Write or Close, which truncate it so the
+    file holds only the current run's records.
+*/
+func OpenJSONL(cfg *config.Config) (*JSONLSink, error) {
+	path := outputPath(cfg, ".jsonl")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %v", filepath.Dir(path), err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	return &JSONLSink{file: file, lastSync: time.Now()}, nil
+}
+
+// Path returns the path of the file being written.
+func (s *JSONLSink) Path() string {
+	return s.file.Name()
+}
+
+// Write appends record to the file as one line of JSON.
+func (s *JSONLSink) Write(record *parser.Record) error {
+	line, err := jsonLine(record)
+	if err != nil {
+		return err
+	}
+	if err := s.start(); err != nil {
+		return err
+	}
+	if _, err := s.file.Write(line); err != nil {
+		return fmt.Errorf("failed to write %s: %v", s.Path(), err)
+	}
+	if time.Since(s.lastSync) >= syncInterval {
+		s.lastSync = time.Now()
+		if err := s.file.Sync(); err != nil {
+			return fmt.Errorf("failed to write %s: %v", s.Path(), err)
+		}
+	}
+	return nil
+}
+
+// Close syncs and closes the file, emptying it if nothing was written.
+func (s *JSONLSink) Close() error {
+	if err := s.start(); err != nil {
+		s.file.Close()
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		s.file.Close()
+		return fmt.Errorf("failed to write %s: %v", s.Path(), err)
+	}
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %v", s.Path(), err)
+	}
+	return nil
+}
+
+// Discard closes the file without syncing it. Records already written are kept; if none
+// were, the previous run's file is left as it was.
+func (s *JSONLSink) Discard() {
+	s.file.Close()
+}
+
+// start truncates the previous run's records before the first line is written.
+func (s *JSONLSink) start() error {
+	if s.started {
+		return nil
+	}
+	if err := s.file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to write %s: %v", s.Path(), err)
+	}
+	s.started = true
+	return nil
+}
+
+// writeJSONL writes records to w, one line of JSON each.
+func writeJSONL(w io.Writer, records []*parser.Record) error {
+	for _, record := range records {
+		line, err := jsonLine(record)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(line); err != nil {
+			return fmt.Errorf("failed to write JSON lines: %v", err)
+		}
+	}
+	return nil
+}
+
+// jsonLine encodes record as compact JSON followed by a newline.
+func jsonLine(record *parser.Record) ([]byte, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JSON: %v", err)
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,130 @@
+// File: pkg/storage/jsonl_test.go
+
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/heinrichb/scrapey-cli/pkg/parser"
+)
+
+// TestJSONLSink verifies that records are readable line by line while the sink is open
+// and that a new sink replaces the previous run's file.
+func TestJSONLSink(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	cfg := testConfig(dir)
+	path := filepath.Join(dir, "results.jsonl")
+
+	for run := 0; run < 2; run++ {
+		sink, err := OpenJSONL(cfg)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if sink.Path() != path {
+			t.Errorf("Unexpected path %s", sink.Path())
+		}
+		for i, record := range testRecords() {
+			if err := sink.Write(record); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			// The file holds every record written so far, as complete lines.
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+			if len(lines) != i+1 || !strings.HasSuffix(string(data), "\n") {
+				t.Fatalf("Expected %d complete lines, got %q", i+1, data)
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal([]byte(lines[i]), &got); err != nil || got["url"] != record.URL {
+				t.Errorf("Expected line %d to hold %s, got %q (%v)", i, record.URL, lines[i], err)
+			}
+		}
+		if err := sink.Close(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open output: %v", err)
+	}
+	defer file.Close()
+	count := 0
+	for scanner := bufio.NewScanner(file); scanner.Scan(); {
+		count++
+	}
+	if count != len(testRecords()) {
+		t.Errorf("Expected the second run to replace the first, got %d lines", count)
+	}
+}
+
+// TestJSONLSinkErrors verifies encoding failures and unwritable paths.
+func TestJSONLSinkErrors(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := OpenJSONL(testConfig(dir))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := sink.Write(&parser.Record{Fields: map[string]interface{}{"f": func() {}}}); err == nil {
+		t.Error("Expected an encoding error")
+	}
+	sink.Close()
+
+	blocker := filepath.Join(dir, "file")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := OpenJSONL(testConfig(filepath.Join(blocker, "sub"))); err == nil {
+		t.Error("Expected an error when the save path cannot be created")
+	}
+}
+
+// TestJSONLSinkDiscard verifies that the previous run's file survives until the first
+// record, and is emptied by a Close with nothing written.
+func TestJSONLSinkDiscard(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "results.jsonl")
+	if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	sink, err := OpenJSONL(testConfig(dir))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sink.Discard()
+	if data, _ := os.ReadFile(path); string(data) != "{}\n" {
+		t.Errorf("Expected the previous output to survive, got %q", data)
+	}
+
+	sink, err = OpenJSONL(testConfig(dir))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(path); len(data) != 0 {
+		t.Errorf("Expected an empty file after a run without records, got %q", data)
+	}
+}
+
+// TestSaveDataJSONL verifies batch JSONL output through SaveData.
+func TestSaveDataJSONL(t *testing.T) {
+	path, err := SaveData(testRecords(), testConfig(t.TempDir()), JSONL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if lines := strings.Count(string(data), "\n"); lines != 2 || !strings.HasPrefix(string(data), `{"url":"https://example.com/"`) {
+		t.Errorf("Expected two compact lines, got %q", data)
+	}
+}
@@ -0,0 +1,425 @@
+// File: pkg/storage/sqlite.go
+
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+	"github.com/heinrichb/scrapey-cli/pkg/parser"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// crawlsTable is the side table holding one row of metadata per saved crawl.
+const crawlsTable = "crawls"
+
+/*
+sqliteTable describes the table a crawl's records are saved to.
+
+Fields:
+  - name: The table name: "items" when ParseRules.Items is configured, else "pages".
+  - keys: The primary key columns: "url", plus "position" for items, since a page
+    yields several of them.
+  - meta: The columns every row carries besides its fields, with their types.
+*/
+type sqliteTable struct {
+	name string
+	keys []string
+	meta []sqliteColumn
+}
+
+// sqliteColumn is a column name and its declared type.
+type sqliteColumn struct {
+	name string
+	typ  string
+}
+
+/*
+writeSQLite saves records to the SQLite database at path, creating it as needed.
+
+Parameters:
+  - path: The database file; it is opened in place, so earlier runs' rows are kept.
+  - records: The records to save.
+  - pages: The URLs of the pages parsed in the crawl; nil to take them from records.
+  - cfg: Supplies the configured fields (columns) and the crawl metadata.
+
+Returns:
+  - An error if the database could not be opened or updated. Nothing is saved then.
+
+Notes:
+  - Records go to a "pages" table, one row per page keyed by "url", or, when
+    ParseRules.Items is configured, an "items" table keyed by "url" and "position" (the
+    item's index on its page). Rows also hold "fetchedAt", "crawlId" (the crawl that last
+    wrote them) and "structured" (the page's structured data as JSON).
+  - Field columns follow the CSV order (see writeCSV): objects are expanded into
+    "name.sub" columns, and lists, such as table rows, are stored as JSON, which SQLite's
+    json_each can query. Numbers are REAL, booleans INTEGER (0 or 1) and dates RFC 3339
+    TEXT; a column's type comes from its rule's transforms or else from its values.
+  - Tables are created on first use, and columns for newly configured fields are added
+    on later runs. Field names that clash with a metadata column are prefixed "fields.".
+  - Saving upserts by source URL: a page's row is replaced, and so are all of its items,
+    even when the page now has none, while rows of pages not crawled in this run are kept.
+    Columns this run does not write are cleared in replaced rows.
+  - Each save adds a row to the "crawls" table with the base URL, routes, the first and
+    last fetch times and the number of pages and records.
+  - Everything is written in one transaction, so an interrupted save changes nothing.
+*/
+func writeSQLite(path string, records []*parser.Record, pages []string, cfg *config.Config) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %v", filepath.Dir(path), err)
+	}
+	// An immediate transaction takes the write lock up front, so concurrent runs wait
+	// for each other (up to the busy timeout) instead of failing mid-save.
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000&_txlock=immediate")
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	if err := saveSQLite(tx, records, pages, cfg); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
+
+// saveSQLite records the crawl and upserts its records within tx.
+func saveSQLite(tx *sql.Tx, records []*parser.Record, pages []string, cfg *config.Config) error {
+	// Pages that yielded records were parsed, whether or not pages lists them.
+	pages = append([]string(nil), pages...)
+	for _, record := range records {
+		pages = append(pages, record.URL)
+	}
+	crawlID, err := insertCrawl(tx, records, pages, cfg)
+	if err != nil {
+		return err
+	}
+
+	table := recordTable(cfg)
+	rows := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		rows[i] = make(map[string]interface{})
+		for name, value := range record.Fields {
+			expandObjects(name, value, rows[i])
+		}
+	}
+	fields := csvColumns(rows, cfg)
+	reserved := make(map[string]bool)
+	for _, column := range table.meta {
+		reserved[strings.ToLower(column.name)] = true
+	}
+	columns := append([]sqliteColumn(nil), table.meta...)
+	types := fieldTypes(cfg)
+	for _, field := range fields {
+		name := field
+		if reserved[strings.ToLower(name)] {
+			name = "fields." + name
+		}
+		typ := types[field]
+		if typ == "" {
+			typ = valueType(rows, field)
+		}
+		columns = append(columns, sqliteColumn{name: name, typ: typ})
+	}
+	stale, err := ensureTable(tx, table, columns)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, len(columns))
+	marks := make([]string, len(columns))
+	var updates []string
+	for i, column := range columns {
+		names[i] = quoteIdent(column.name)
+		marks[i] = "?"
+		if !containsString(table.keys, column.name) {
+			updates = append(updates, names[i]+" = excluded."+names[i])
+		}
+	}
+	// Fields from earlier runs that this one did not write must not keep their old values.
+	for _, name := range stale {
+		updates = append(updates, quoteIdent(name)+" = NULL")
+	}
+	keys := make([]string, len(table.keys))
+	for i, key := range table.keys {
+		keys[i] = quoteIdent(key)
+	}
+	insert, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO UPDATE SET %s",
+		quoteIdent(table.name), strings.Join(names, ", "), strings.Join(marks, ", "),
+		strings.Join(keys, ", "), strings.Join(updates, ", ")))
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+
+	// A page's items are replaced as a whole, so items it no longer lists are dropped,
+	// all of them if it lists none.
+	positions := make(map[string]int)
+	if len(table.keys) > 1 {
+		for _, page := range pages {
+			if _, ok := positions[page]; ok {
+				continue
+			}
+			positions[page] = 0
+			if _, err := tx.Exec("DELETE FROM "+quoteIdent(table.name)+" WHERE url = ?", page); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, record := range records {
+		args := []interface{}{record.URL}
+		if len(table.keys) > 1 {
+			args = append(args, positions[record.URL])
+			positions[record.URL]++
+		}
+		var fetchedAt interface{}
+		if !record.FetchedAt.IsZero() {
+			fetchedAt = record.FetchedAt.UTC().Format(time.RFC3339)
+		}
+		var structured interface{}
+		if record.Structured != nil {
+			structured = jsonCell(record.Structured)
+		}
+		args = append(args, fetchedAt, crawlID, structured)
+		for _, field := range fields {
+			args = append(args, sqlValue(rows[i][field]))
+		}
+		if _, err := insert.Exec(args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordTable returns the table for cfg's record type.
+func recordTable(cfg *config.Config) sqliteTable {
+	meta := []sqliteColumn{
+		{name: "fetchedAt", typ: "TEXT"},
+		{name: "crawlId", typ: "INTEGER"},
+		{name: "structured", typ: "TEXT"},
+	}
+	if cfg.ParseRules.Items != nil {
+		return sqliteTable{
+			name: "items",
+			keys: []string{"url", "position"},
+			meta: append([]sqliteColumn{{name: "url", typ: "TEXT NOT NULL"}, {name: "position", typ: "INTEGER NOT NULL"}}, meta...),
+		}
+	}
+	return sqliteTable{
+		name: "pages",
+		keys: []string{"url"},
+		meta: append([]sqliteColumn{{name: "url", typ: "TEXT NOT NULL"}}, meta...),
+	}
+}
+
+// insertCrawl adds the crawl's metadata to the crawls table and returns its id.
+func insertCrawl(tx *sql.Tx, records []*parser.Record, pages []string, cfg *config.Config) (int64, error) {
+	_, err := tx.Exec(`CREATE TABLE IF NOT EXISTS ` + crawlsTable + ` (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		baseUrl TEXT,
+		routes TEXT,
+		startedAt TEXT,
+		finishedAt TEXT,
+		savedAt TEXT NOT NULL,
+		pages INTEGER NOT NULL,
+		records INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return 0, err
+	}
+
+	var first, last time.Time
+	crawled := make(map[string]bool)
+	for _, page := range pages {
+		crawled[page] = true
+	}
+	for _, record := range records {
+		if record.FetchedAt.IsZero() {
+			continue
+		}
+		if first.IsZero() || record.FetchedAt.Before(first) {
+			first = record.FetchedAt
+		}
+		if record.FetchedAt.After(last) {
+			last = record.FetchedAt
+		}
+	}
+	var routes interface{}
+	if len(cfg.URL.Routes) > 0 {
+		routes = jsonCell(cfg.URL.Routes)
+	}
+	result, err := tx.Exec(`INSERT INTO `+crawlsTable+` (baseUrl, routes, startedAt, finishedAt, savedAt, pages, records)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		cfg.URL.Base, routes, sqlValue(first), sqlValue(last),
+		time.Now().UTC().Format(time.RFC3339), len(crawled), len(records))
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// ensureTable creates table with columns, or adds the columns an existing table lacks.
+// It returns the existing table's other columns, which columns does not list.
+func ensureTable(tx *sql.Tx, table sqliteTable, columns []sqliteColumn) ([]string, error) {
+	rows, err := tx.Query("SELECT name FROM pragma_table_info(?)", table.name)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		names = append(names, name)
+		existing[strings.ToLower(name)] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(existing) == 0 {
+		defs := make([]string, 0, len(columns)+1)
+		for _, column := range columns {
+			defs = append(defs, quoteIdent(column.name)+" "+column.typ)
+		}
+		keys := make([]string, len(table.keys))
+		for i, key := range table.keys {
+			keys[i] = quoteIdent(key)
+		}
+		defs = append(defs, "PRIMARY KEY ("+strings.Join(keys, ", ")+")")
+		_, err := tx.Exec("CREATE TABLE " + quoteIdent(table.name) + " (\n\t" + strings.Join(defs, ",\n\t") + "\n)")
+		return nil, err
+	}
+	listed := make(map[string]bool)
+	for _, column := range columns {
+		listed[strings.ToLower(column.name)] = true
+		if existing[strings.ToLower(column.name)] {
+			continue
+		}
+		// Added columns cannot be NOT NULL without a default; only key columns are.
+		typ := strings.TrimSuffix(column.typ, " NOT NULL")
+		if _, err := tx.Exec("ALTER TABLE " + quoteIdent(table.name) + " ADD COLUMN " + quoteIdent(column.name) + " " + typ); err != nil {
+			return nil, err
+		}
+	}
+	var other []string
+	for _, name := range names {
+		if !listed[strings.ToLower(name)] {
+			other = append(other, name)
+		}
+	}
+	return other, nil
+}
+
+// fieldTypes returns the column types implied by the configured rules: REAL for fields
+// converted with "toNumber", TEXT for dates and tables.
+func fieldTypes(cfg *config.Config) map[string]string {
+	types := make(map[string]string)
+	rules := columnRules(cfg)
+	for _, name := range cfg.FieldNames() {
+		addRuleTypes(name, rules[name], types)
+	}
+	return types
+}
+
+// addRuleTypes records the types of the columns rule produces, as ruleColumns names them.
+func addRuleTypes(name string, rule config.FieldRule, types map[string]string) {
+	if len(rule.Fields) > 0 && rule.Type != config.RuleTable {
+		for sub, subRule := range rule.Fields {
+			addRuleTypes(name+"."+sub, subRule, types)
+		}
+		return
+	}
+	if rule.Type == config.RuleTable {
+		types[name] = "TEXT"
+		return
+	}
+	for _, spec := range rule.Transforms {
+		switch spec.Name {
+		case "toNumber":
+			types[name] = "REAL"
+		case "toDate":
+			types[name] = "TEXT"
+		}
+	}
+}
+
+// valueType infers a column's type from its values: REAL if all are numbers, INTEGER if
+// all are booleans, TEXT otherwise.
+func valueType(rows []map[string]interface{}, column string) string {
+	typ := ""
+	for _, row := range rows {
+		var t string
+		switch row[column].(type) {
+		case nil:
+			continue
+		case float64, float32, int, int64:
+			t = "REAL"
+		case bool:
+			t = "INTEGER"
+		default:
+			return "TEXT"
+		}
+		if typ != "" && typ != t {
+			return "TEXT"
+		}
+		typ = t
+	}
+	if typ == "" {
+		return "TEXT"
+	}
+	return typ
+}
+
+// expandObjects stores value in row under key, expanding objects into "key.sub" entries.
+func expandObjects(key string, value interface{}, row map[string]interface{}) {
+	switch v := value.(type) {
+	case nil:
+	case map[string]interface{}:
+		for sub, x := range v {
+			expandObjects(key+"."+sub, x, row)
+		}
+	default:
+		row[key] = v
+	}
+}
+
+// sqlValue converts a field value to one SQLite stores: times as RFC 3339 text and
+// lists as JSON.
+func sqlValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, string, float64, int, int64, bool:
+		return v
+	case time.Time:
+		if v.IsZero() {
+			return nil
+		}
+		return v.UTC().Format(time.RFC3339)
+	case []interface{}, []string, []map[string]interface{}:
+		return jsonCell(v)
+	default:
+		return cellText(v)
+	}
+}
+
+// quoteIdent quotes name as an SQL identifier.
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
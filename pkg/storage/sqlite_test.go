@@ -0,0 +1,265 @@
+// File: pkg/storage/sqlite_test.go
+
+// The SQLite driver needs cgo; without it every save fails with the driver's stub error.
+
+//go:build cgo
+
+package storage
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+	"github.com/heinrichb/scrapey-cli/pkg/parser"
+)
+
+// openTestDB opens the database SaveData wrote to path.
+func openTestDB(t *testing.T, path string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// TestSaveDataSQLite verifies the pages table's schema, typed values and crawl metadata.
+func TestSaveDataSQLite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	cfg := testConfig(dir)
+	cfg.URL.Base = "https://example.com"
+	cfg.ParseRules.Title = "h1"
+	cfg.ParseRules.Fields = map[string]config.FieldRule{
+		"price": {Selector: ".price", Transforms: []config.TransformSpec{{Name: "toNumber"}}},
+		"url":   {Selector: ".canonical"},
+	}
+	records := testRecords()
+	records[0].Fields["price"] = 9.5
+	records[0].Fields["inStock"] = true
+	records[0].Fields["url"] = "https://example.com/canonical"
+	records[0].Structured = &parser.StructuredData{JSONLD: []map[string]interface{}{{"@type": "Product"}}}
+
+	path, err := SaveData(records, cfg, SQLite)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if path != filepath.Join(dir, "results.db") {
+		t.Errorf("Unexpected path %s", path)
+	}
+
+	db := openTestDB(t, path)
+	rows, err := db.Query(`SELECT name, type FROM pragma_table_info('pages')`)
+	if err != nil {
+		t.Fatalf("Failed to read schema: %v", err)
+	}
+	var columns []string
+	types := make(map[string]string)
+	for rows.Next() {
+		var name, typ string
+		if err := rows.Scan(&name, &typ); err != nil {
+			t.Fatalf("Failed to read schema: %v", err)
+		}
+		columns = append(columns, name)
+		types[name] = typ
+	}
+	rows.Close()
+	expected := []string{"url", "fetchedAt", "crawlId", "structured", "title", "price", "fields.url", "example", "inStock", "list", "nested.key"}
+	if len(columns) != len(expected) {
+		t.Fatalf("Expected columns %v, got %v", expected, columns)
+	}
+	for i := range expected {
+		if columns[i] != expected[i] {
+			t.Fatalf("Expected columns %v, got %v", expected, columns)
+		}
+	}
+	if types["price"] != "REAL" || types["inStock"] != "INTEGER" || types["title"] != "TEXT" {
+		t.Errorf("Unexpected column types %v", types)
+	}
+
+	var (
+		price      float64
+		inStock    int
+		list       string
+		nested     string
+		canonical  string
+		fetchedAt  string
+		structured string
+	)
+	err = db.QueryRow(`SELECT price, inStock, list, "nested.key", "fields.url", fetchedAt, structured FROM pages WHERE url = ?`, records[0].URL).
+		Scan(&price, &inStock, &list, &nested, &canonical, &fetchedAt, &structured)
+	if err != nil {
+		t.Fatalf("Failed to read row: %v", err)
+	}
+	if price != 9.5 || inStock != 1 || list != `["a","b"]` || nested != "value" || canonical != "https://example.com/canonical" {
+		t.Errorf("Unexpected values %v %v %q %q %q", price, inStock, list, nested, canonical)
+	}
+	if fetchedAt != "2025-03-01T12:00:00Z" || structured == "" {
+		t.Errorf("Unexpected metadata %q %q", fetchedAt, structured)
+	}
+
+	// The list is stored as JSON that SQLite can query.
+	var item string
+	if err := db.QueryRow(`SELECT value FROM pages, json_each(pages.list) WHERE url = ? LIMIT 1`, records[0].URL).Scan(&item); err != nil || item != "a" {
+		t.Errorf("Expected json_each to read the list, got %q (%v)", item, err)
+	}
+
+	var baseURL string
+	var pages, count int
+	if err := db.QueryRow(`SELECT baseUrl, pages, records FROM crawls`).Scan(&baseURL, &pages, &count); err != nil {
+		t.Fatalf("Failed to read crawl: %v", err)
+	}
+	if baseURL != cfg.URL.Base || pages != 2 || count != 2 {
+		t.Errorf("Unexpected crawl metadata %q %d %d", baseURL, pages, count)
+	}
+}
+
+// TestSaveDataSQLiteIncremental verifies that later runs upsert by URL, keep other pages'
+// rows, add columns for new fields and record each crawl.
+func TestSaveDataSQLiteIncremental(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig(dir)
+	if _, err := SaveData(testRecords(), cfg, SQLite); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	cfg.ParseRules.Fields = map[string]config.FieldRule{"rating": {Selector: ".rating"}}
+	second := []*parser.Record{{
+		URL:       "https://example.com/",
+		Fields:    map[string]interface{}{"example": "updated", "rating": "5"},
+		FetchedAt: time.Date(2025, 3, 2, 12, 0, 0, 0, time.UTC),
+	}}
+	path, err := SaveData(second, cfg, SQLite)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	db := openTestDB(t, path)
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pages`).Scan(&count); err != nil || count != 2 {
+		t.Errorf("Expected 2 pages, got %d (%v)", count, err)
+	}
+	var example, rating string
+	var crawlID int
+	err = db.QueryRow(`SELECT example, rating, crawlId FROM pages WHERE url = ?`, "https://example.com/").Scan(&example, &rating, &crawlID)
+	if err != nil || example != "updated" || rating != "5" || crawlID != 2 {
+		t.Errorf("Expected the page to be updated, got %q %q %d (%v)", example, rating, crawlID, err)
+	}
+	var kept sql.NullString
+	if err := db.QueryRow(`SELECT rating FROM pages WHERE url = ?`, "https://example.com/second").Scan(&kept); err != nil || kept.Valid {
+		t.Errorf("Expected the other page to be kept without a rating, got %v (%v)", kept, err)
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM crawls`).Scan(&count); err != nil || count != 2 {
+		t.Errorf("Expected 2 crawls, got %d (%v)", count, err)
+	}
+}
+
+// TestSaveDataSQLiteRemovedField verifies that re-saving a page without one of its fields
+// clears that column instead of keeping the earlier value.
+func TestSaveDataSQLiteRemovedField(t *testing.T) {
+	cfg := testConfig(t.TempDir())
+	first := []*parser.Record{{
+		URL:    "https://example.com/",
+		Fields: map[string]interface{}{"title": "Old", "price": "10"},
+	}}
+	if _, err := SaveData(first, cfg, SQLite); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	second := []*parser.Record{{
+		URL:    "https://example.com/",
+		Fields: map[string]interface{}{"title": "New"},
+	}}
+	path, err := SaveData(second, cfg, SQLite)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	db := openTestDB(t, path)
+	var title string
+	var price sql.NullString
+	err = db.QueryRow(`SELECT title, price FROM pages WHERE url = ?`, "https://example.com/").Scan(&title, &price)
+	if err != nil || title != "New" || price.Valid {
+		t.Errorf("Expected the title to be updated and the price cleared, got %q %v (%v)", title, price, err)
+	}
+}
+
+// TestSaveDataSQLiteItems verifies that item records are keyed by URL and position and
+// that a page's items are replaced as a whole, even by none.
+func TestSaveDataSQLiteItems(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig(dir)
+	cfg.ParseRules.Items = &config.FieldRule{
+		Selector: ".product",
+		Fields:   map[string]config.FieldRule{"name": {Selector: ".name"}},
+	}
+	items := func(names ...string) []*parser.Record {
+		var records []*parser.Record
+		for _, name := range names {
+			records = append(records, &parser.Record{URL: "https://example.com/shop", Fields: map[string]interface{}{"name": name}})
+		}
+		return records
+	}
+
+	if _, err := SaveData(items("a", "b", "c"), cfg, SQLite); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	path, err := SaveData(items("d", "e"), cfg, SQLite)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	db := openTestDB(t, path)
+	rows, err := db.Query(`SELECT position, name FROM items WHERE url = ? ORDER BY position`, "https://example.com/shop")
+	if err != nil {
+		t.Fatalf("Failed to read items: %v", err)
+	}
+	defer rows.Close()
+	var got []string
+	for i := 0; rows.Next(); i++ {
+		var position int
+		var name string
+		if err := rows.Scan(&position, &name); err != nil {
+			t.Fatalf("Failed to read items: %v", err)
+		}
+		if position != i {
+			t.Errorf("Expected position %d, got %d", i, position)
+		}
+		got = append(got, name)
+	}
+	if len(got) != 2 || got[0] != "d" || got[1] != "e" {
+		t.Errorf("Expected items [d e], got %v", got)
+	}
+
+	// The page was crawled again but lists nothing now.
+	if _, err := SaveCrawl(nil, []string{"https://example.com/shop"}, cfg, SQLite); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var count, pages int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM items WHERE url = ?`, "https://example.com/shop").Scan(&count); err != nil || count != 0 {
+		t.Errorf("Expected the page's items to be removed, got %d (%v)", count, err)
+	}
+	if err := db.QueryRow(`SELECT pages FROM crawls ORDER BY id DESC LIMIT 1`).Scan(&pages); err != nil || pages != 1 {
+		t.Errorf("Expected the crawl to count the page, got %d (%v)", pages, err)
+	}
+}
+
+// TestSaveDataSQLiteErrors verifies that a path that is not a database fails without
+// being modified.
+func TestSaveDataSQLiteErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "results.db")
+	if err := os.WriteFile(path, []byte("not a database, just some text"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := SaveData(testRecords(), testConfig(dir), SQLite); err == nil {
+		t.Error("Expected an error for a file that is not a database")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "not a database, just some text" {
+		t.Errorf("Expected the file to be left alone, got %q (%v)", data, err)
+	}
+}
@@ -2,6 +2,17 @@
 
 package storage
 
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+	"github.com/heinrichb/scrapey-cli/pkg/parser"
+)
+
 /*
 StorageOption enumerates the types of storage we might support.
 
@@ -12,10 +23,15 @@ Constants:
 	Excel     - Data stored in Excel format.
 	MongoDB   - Data stored in a MongoDB database.
 	MySQL     - Data stored in a MySQL database.
+	CSV       - Data stored as comma-separated values, one row per record.
+	JSONL     - Data stored as newline-delimited JSON, one record per line.
+	SQLite    - Data stored in a local SQLite database, updated across runs.
 
 Usage:
 
 	These constants are used with SaveData to specify the desired output format.
+	JSONL and XML can also be streamed while a crawl runs (see OpenJSONL and OpenXML).
+	ParseStorageOption maps the names used in Storage.OutputFormats to them.
 */
 type StorageOption int
 
@@ -25,34 +41,175 @@ const (
 	Excel
 	MongoDB
 	MySQL
+	CSV
+	JSONL
+	SQLite
 )
 
+// optionNames maps each StorageOption to its name in Storage.OutputFormats.
+var optionNames = map[StorageOption]string{
+	JSON:    "json",
+	XML:     "xml",
+	Excel:   "excel",
+	MongoDB: "mongodb",
+	MySQL:   "mysql",
+	CSV:     "csv",
+	JSONL:   "jsonl",
+	SQLite:  "sqlite",
+}
+
+// optionAliases are further names accepted by ParseStorageOption.
+var optionAliases = map[string]StorageOption{
+	"xlsx": Excel,
+}
+
+// String returns the option's name as written in Storage.OutputFormats.
+func (o StorageOption) String() string {
+	if name, ok := optionNames[o]; ok {
+		return name
+	}
+	return fmt.Sprintf("StorageOption(%d)", int(o))
+}
+
 /*
-SaveData accepts extracted data as a map of strings and stores it in the format specified
-by the option parameter.
+ParseStorageOption returns the StorageOption for an entry of Storage.OutputFormats.
 
 Parameters:
-  - data: A map where each key/value pair represents a piece of extracted data.
-  - option: A StorageOption value indicating the format in which to store the data.
+  - name: The format name, e.g. "json"; matching is case-insensitive. "xlsx" is accepted
+    for Excel.
 
-Usage:
+Returns:
+  - The matching StorageOption, or an error if name is not a known format.
+*/
+func ParseStorageOption(name string) (StorageOption, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if option, ok := optionAliases[name]; ok {
+		return option, nil
+	}
+	for option, n := range optionNames {
+		if n == name {
+			return option, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown output format %q", name)
+}
+
+/*
+SaveData writes the parsed records to Storage.SavePath in the format specified by option.
+
+Parameters:
+  - records: The records of a crawl, as returned by parser.ParseHTML and post-processed.
+  - cfg: The loaded configuration; Storage.SavePath and Storage.FileName name the output,
+    and Storage.CSV and the configured fields shape CSV output.
+  - option: A StorageOption value indicating the format in which to store the data.
 
-	This function serves as a placeholder for future storage implementations.
-	It may later be extended into a strategy pattern to support multiple storage formats,
-	such as JSON, XML, Excel, MongoDB, or MySQL.
+Returns:
+  - The path of the file written.
+  - An error if the format is not supported yet or the file could not be written.
 
 Example:
 
-	err := SaveData(myData, JSON)
+	path, err := SaveData(records, cfg, JSON)
 	if err != nil {
 	    // Handle the error accordingly.
 	}
 
 Notes:
-  - Currently, this function is a stub and does not perform any storage operations.
-  - It always returns nil.
+  - JSON output is "<savePath>/<fileName>.json": a pretty-printed array with one object
+    per record ("[]" when there are none).
+  - JSONL output is "<savePath>/<fileName>.jsonl", one compact JSON object per line.
+  - CSV output is "<savePath>/<fileName>.csv"; see writeCSV for the columns.
+  - XML output is "<savePath>/<fileName>.xml", shaped by Storage.XML; see xmlWriter.
+  - Excel output is "<savePath>/<fileName>.xlsx"; see writeExcel for the sheet layout.
+  - SQLite output is the database "<savePath>/<fileName>.db", which is updated in place
+    so that it accumulates the results of every run; see writeSQLite for the schema.
+  - Missing directories are created. Other files are written to a temporary file beside
+    them and renamed into place, so an interrupted run never leaves a half-written file.
 */
-func SaveData(data map[string]string, option StorageOption) error {
-	// Stub: for now, do nothing.
+func SaveData(records []*parser.Record, cfg *config.Config, option StorageOption) (string, error) {
+	return SaveCrawl(records, nil, cfg, option)
+}
+
+/*
+SaveCrawl is SaveData for a crawl whose parsed pages are known.
+
+Parameters:
+  - records: The records of the crawl.
+  - pages: The URL of every page parsed in the crawl, including pages that yielded no
+    records; nil to take the pages from the records.
+  - cfg: The loaded configuration.
+  - option: The format to store the data in.
+
+Returns:
+  - The path of the file written, or an error, as for SaveData.
+
+Notes:
+  - Only SQLite output uses pages: the saved items of every listed page are replaced,
+    so a page that no longer lists any items has its old ones removed.
+*/
+func SaveCrawl(records []*parser.Record, pages []string, cfg *config.Config, option StorageOption) (string, error) {
+	switch option {
+	case JSON:
+		path := outputPath(cfg, ".json")
+		return path, writeFileAtomic(path, func(w io.Writer) error {
+			return writeJSON(w, records)
+		})
+	case JSONL:
+		path := outputPath(cfg, ".jsonl")
+		return path, writeFileAtomic(path, func(w io.Writer) error {
+			return writeJSONL(w, records)
+		})
+	case CSV:
+		path := outputPath(cfg, ".csv")
+		return path, writeFileAtomic(path, func(w io.Writer) error {
+			return writeCSV(w, records, cfg)
+		})
+	case Excel:
+		path := outputPath(cfg, ".xlsx")
+		return path, writeFileAtomic(path, func(w io.Writer) error {
+			return writeExcel(w, records, cfg)
+		})
+	case XML:
+		path := outputPath(cfg, ".xml")
+		return path, writeFileAtomic(path, func(w io.Writer) error {
+			return writeXML(w, records, cfg)
+		})
+	case SQLite:
+		path := outputPath(cfg, ".db")
+		return path, writeSQLite(path, records, pages, cfg)
+	default:
+		return "", fmt.Errorf("output format %s is not supported yet", option)
+	}
+}
+
+// outputPath returns "<savePath>/<fileName><ext>".
+func outputPath(cfg *config.Config, ext string) string {
+	return filepath.Join(cfg.Storage.SavePath, cfg.Storage.FileName+ext)
+}
+
+// writeXML streams records to w as an XML document.
+func writeXML(w io.Writer, records []*parser.Record, cfg *config.Config) error {
+	xw, err := newXMLWriter(w, cfg)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := xw.Write(record); err != nil {
+			return err
+		}
+	}
+	return xw.Close()
+}
+
+// writeJSON encodes records to w as an indented JSON array.
+func writeJSON(w io.Writer, records []*parser.Record) error {
+	if records == nil {
+		records = []*parser.Record{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(records); err != nil {
+		return fmt.Errorf("failed to encode JSON: %v", err)
+	}
 	return nil
 }
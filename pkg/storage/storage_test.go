@@ -2,23 +2,175 @@
 
 package storage
 
-import "testing"
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
 
-// TestSaveData verifies that SaveData always returns nil regardless of the input.
-// This ensures full test coverage for the stub implementation.
-func TestSaveData(t *testing.T) {
-	// Test with non-empty data.
-	testData := map[string]string{"example": "data"}
-	options := []StorageOption{JSON, XML, Excel, MongoDB, MySQL}
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+	"github.com/heinrichb/scrapey-cli/pkg/parser"
+)
 
-	for _, opt := range options {
-		if err := SaveData(testData, opt); err != nil {
-			t.Errorf("SaveData returned an error for option %v: %v", opt, err)
-		}
+// testRecords returns a small set of records with nested and list fields.
+func testRecords() []*parser.Record {
+	return []*parser.Record{
+		{
+			URL: "https://example.com/",
+			Fields: map[string]interface{}{
+				"example": "data",
+				"list":    []string{"a", "b"},
+				"nested":  map[string]interface{}{"key": "value"},
+			},
+			FetchedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			URL:    "https://example.com/second",
+			Fields: map[string]interface{}{"example": "more"},
+		},
 	}
+}
+
+// testConfig returns a config that saves under dir.
+func testConfig(dir string) *config.Config {
+	cfg := &config.Config{}
+	cfg.Storage.SavePath = dir
+	cfg.Storage.FileName = "results"
+	return cfg
+}
+
+// TestParseStorageOption verifies the mapping from OutputFormats names to options.
+func TestParseStorageOption(t *testing.T) {
+	cases := []struct {
+		desc      string
+		name      string
+		expected  StorageOption
+		expectErr bool
+	}{
+		{desc: "JSON", name: "json", expected: JSON},
+		{desc: "JSONL", name: "jsonl", expected: JSONL},
+		{desc: "CSV", name: "csv", expected: CSV},
+		{desc: "Excel alias", name: "xlsx", expected: Excel},
+		{desc: "SQLite", name: "sqlite", expected: SQLite},
+		{desc: "Case and spaces are ignored", name: " XML ", expected: XML},
+		{desc: "Unknown format", name: "yaml", expectErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := ParseStorageOption(tc.name)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("Expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestSaveDataJSON verifies that records are written as a pretty-printed JSON array,
+// creating missing directories and leaving no temporary files behind.
+func TestSaveDataJSON(t *testing.T) {
+	cases := []struct {
+		desc     string
+		records  []*parser.Record
+		expected int
+	}{
+		{desc: "Records", records: testRecords(), expected: 2},
+		{desc: "No records", records: nil, expected: 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "nested", "out")
+			path, err := SaveData(tc.records, testConfig(dir), JSON)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if path != filepath.Join(dir, "results.json") {
+				t.Errorf("Unexpected path %s", path)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			var got []map[string]interface{}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Output is not a JSON array: %v\n%s", err, data)
+			}
+			if got == nil || len(got) != tc.expected {
+				t.Errorf("Expected %d records, got %s", tc.expected, data)
+			}
+			if tc.expected > 0 {
+				if !strings.Contains(string(data), "\n  {\n    \"url\": \"https://example.com/\"") {
+					t.Errorf("Expected indented output, got %s", data)
+				}
+				if got[0]["fields"].(map[string]interface{})["nested"].(map[string]interface{})["key"] != "value" {
+					t.Errorf("Expected nested fields to round-trip, got %v", got[0])
+				}
+			}
+
+			entries, _ := os.ReadDir(dir)
+			if len(entries) != 1 {
+				t.Errorf("Expected only the output file in %s, found %d entries", dir, len(entries))
+			}
+		})
+	}
+}
+
+// TestSaveDataReplaces verifies that an existing file is replaced and that a failed
+// write leaves it untouched.
+func TestSaveDataReplaces(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig(dir)
+	path := filepath.Join(dir, "results.json")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	if _, err := SaveData(testRecords(), cfg, JSON); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "[") {
+		t.Errorf("Expected the file to be replaced, got %s", data)
+	}
+
+	bad := []*parser.Record{{URL: "x", Fields: map[string]interface{}{"f": func() {}}}}
+	if _, err := SaveData(bad, cfg, JSON); err == nil {
+		t.Fatal("Expected an encoding error")
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(data) {
+		t.Errorf("Expected the previous file to survive a failed write")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected the temporary file to be removed, found %d entries", len(entries))
+	}
+}
 
-	// Also test with an empty map.
-	if err := SaveData(map[string]string{}, JSON); err != nil {
-		t.Errorf("SaveData returned an error for empty map: %v", err)
+// TestSaveDataErrors verifies unsupported formats and unwritable paths.
+func TestSaveDataErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := SaveData(testRecords(), testConfig(dir), MongoDB); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+
+	blocker := filepath.Join(dir, "file")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := SaveData(testRecords(), testConfig(filepath.Join(blocker, "sub")), JSON); err == nil {
+		t.Error("Expected an error when the save path cannot be created")
 	}
 }
@@ -0,0 +1,325 @@
+// File: pkg/storage/xml.go
+
+package storage
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/heinrichb/scrapey-cli/pkg/config"
+	"github.com/heinrichb/scrapey-cli/pkg/parser"
+)
+
+/*
+XMLSink streams records to an XML document while a crawl runs.
+
+Fields:
+  - file: The temporary file the document is written to.
+  - xw: Writes the document's elements to file.
+  - err: The first failed Write, after which the document is abandoned.
+
+Usage:
+
+	sink, err := storage.OpenXML(cfg)
+	if err != nil {
+	    // Handle error
+	}
+	// For each record as its page completes:
+	err = sink.Write(record)
+	// After the crawl, or sink.Discard() if it failed:
+	err = sink.Close()
+
+Notes:
+  - The document is built in a temporary file beside the output, so records are not
+    held in memory. Close completes it and renames it into place; until then any
+    previous output is left untouched. After a failed Write, Close discards the
+    document and reports that failure instead. Discard drops the document without
+    touching the previous output.
+  - An XMLSink is not safe for concurrent use; crawler.Crawl never calls its visit
+    function concurrently.
+*/
+type XMLSink struct {
+	file *atomicFile
+	xw   *xmlWriter
+	err  error
+}
+
+/*
+OpenXML starts the document "<savePath>/<fileName>.xml", creating directories as needed.
+
+Parameters:
+  - cfg: The loaded configuration; Storage.SavePath and Storage.FileName name the output,
+    and Storage.XML shapes the document.
+
+Returns:
+  - The sink, or an error if the temporary file could not be created or written.
+*/
+func OpenXML(cfg *config.Config) (*XMLSink, error) {
+	file, err := createAtomic(outputPath(cfg, ".xml"))
+	if err != nil {
+		return nil, err
+	}
+	xw, err := newXMLWriter(file, cfg)
+	if err != nil {
+		file.discard()
+		return nil, err
+	}
+	return &XMLSink{file: file, xw: xw}, nil
+}
+
+// Path returns the path the document is saved to on Close.
+func (s *XMLSink) Path() string {
+	return s.file.path
+}
+
+// Write appends record to the document.
+func (s *XMLSink) Write(record *parser.Record) error {
+	if s.err == nil {
+		s.err = s.xw.Write(record)
+	}
+	return s.err
+}
+
+// Close ends the document and renames it into place. The temporary file is removed
+// if that fails.
+func (s *XMLSink) Close() error {
+	if s.err == nil {
+		s.err = s.xw.Close()
+	}
+	if s.err != nil {
+		s.file.discard()
+		return s.err
+	}
+	return s.file.commit()
+}
+
+// Discard abandons the document and removes its temporary file, leaving any previous
+// output in place.
+func (s *XMLSink) Discard() {
+	s.file.discard()
+}
+
+/*
+xmlWriter streams records to an XML document, one element per record.
+
+Fields:
+  - w: The destination.
+  - enc: The encoder writing to w.
+  - root: The document element (Storage.XML.RootElement).
+  - item: The element wrapping each record (Storage.XML.ItemElement).
+  - attributes: Whether the URL and fetch time are attributes of the item element.
+  - names: The configured field names, which are written first and in this order.
+
+Usage:
+
+	xw, err := newXMLWriter(w, cfg)
+	for _, record := range records {
+	    err = xw.Write(record)
+	}
+	err = xw.Close()
+
+Notes:
+  - Each record is flushed as soon as it is written, so memory use does not grow with
+    the number of records.
+*/
+type xmlWriter struct {
+	w          io.Writer
+	enc        *xml.Encoder
+	root       string
+	item       string
+	attributes bool
+	names      []string
+}
+
+// newXMLWriter writes the XML declaration and opening root element to w.
+func newXMLWriter(w io.Writer, cfg *config.Config) (*xmlWriter, error) {
+	opts := cfg.Storage.XML
+	xw := &xmlWriter{
+		w:          w,
+		enc:        xml.NewEncoder(w),
+		root:       opts.RootElement,
+		item:       opts.ItemElement,
+		attributes: opts.MetadataAttributes,
+		names:      cfg.FieldNames(),
+	}
+	if xw.root == "" {
+		xw.root = "records"
+	}
+	if xw.item == "" {
+		xw.item = "record"
+	}
+	xw.enc.Indent("", "  ")
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return nil, fmt.Errorf("failed to write XML: %v", err)
+	}
+	if err := xw.enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: xw.root}}); err != nil {
+		return nil, fmt.Errorf("failed to write XML: %v", err)
+	}
+	return xw, xw.flush()
+}
+
+/*
+Write appends one record to the document.
+
+Notes:
+  - Fields are child elements named after the field; names that are not valid XML
+    have invalid characters replaced with "_" and keep the original in a "name" attribute.
+  - Objects become nested elements and lists repeat an <item> element per value.
+  - Text is escaped, and characters XML cannot represent are replaced with U+FFFD.
+*/
+func (xw *xmlWriter) Write(record *parser.Record) error {
+	start := xml.StartElement{Name: xml.Name{Local: xw.item}}
+	fetchedAt := ""
+	if !record.FetchedAt.IsZero() {
+		fetchedAt = record.FetchedAt.UTC().Format(time.RFC3339)
+	}
+	if xw.attributes {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "url"}, Value: record.URL})
+		if fetchedAt != "" {
+			start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "fetchedAt"}, Value: fetchedAt})
+		}
+	}
+	if err := xw.enc.EncodeToken(start); err != nil {
+		return fmt.Errorf("failed to write XML: %v", err)
+	}
+	if !xw.attributes {
+		if err := xw.encodeValue("url", record.URL); err != nil {
+			return err
+		}
+		if fetchedAt != "" {
+			if err := xw.encodeValue("fetchedAt", fetchedAt); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range orderedKeys(record.Fields, xw.names) {
+		if err := xw.encodeValue(name, record.Fields[name]); err != nil {
+			return err
+		}
+	}
+	if err := xw.enc.EncodeToken(start.End()); err != nil {
+		return fmt.Errorf("failed to write XML: %v", err)
+	}
+	return xw.flush()
+}
+
+// Close ends the root element and flushes the document.
+func (xw *xmlWriter) Close() error {
+	if err := xw.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: xw.root}}); err != nil {
+		return fmt.Errorf("failed to write XML: %v", err)
+	}
+	if err := xw.flush(); err != nil {
+		return err
+	}
+	if err := xw.enc.Close(); err != nil {
+		return fmt.Errorf("failed to write XML: %v", err)
+	}
+	if _, err := io.WriteString(xw.w, "\n"); err != nil {
+		return fmt.Errorf("failed to write XML: %v", err)
+	}
+	return nil
+}
+
+// flush writes buffered output to the destination.
+func (xw *xmlWriter) flush() error {
+	if err := xw.enc.Flush(); err != nil {
+		return fmt.Errorf("failed to write XML: %v", err)
+	}
+	return nil
+}
+
+// encodeValue writes value as an element called name; nil values are skipped.
+func (xw *xmlWriter) encodeValue(name string, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	start := xml.StartElement{Name: xml.Name{Local: elementName(name)}}
+	if start.Name.Local != name {
+		start.Attr = []xml.Attr{{Name: xml.Name{Local: "name"}, Value: name}}
+	}
+	if err := xw.enc.EncodeToken(start); err != nil {
+		return fmt.Errorf("failed to write XML: %v", err)
+	}
+
+	var err error
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, key := range orderedKeys(v, nil) {
+			if err = xw.encodeValue(key, v[key]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, x := range v {
+			if err = xw.encodeValue("item", x); err != nil {
+				return err
+			}
+		}
+	case []string:
+		for _, x := range v {
+			if err = xw.encodeValue("item", x); err != nil {
+				return err
+			}
+		}
+	case []map[string]interface{}:
+		for _, x := range v {
+			if err = xw.encodeValue("item", x); err != nil {
+				return err
+			}
+		}
+	default:
+		err = xw.enc.EncodeToken(xml.CharData(cellText(v)))
+	}
+	if err == nil {
+		err = xw.enc.EncodeToken(start.End())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write XML: %v", err)
+	}
+	return nil
+}
+
+// elementName turns a field name into a valid XML element name by replacing invalid
+// characters with "_" and prefixing names that cannot start an element.
+func elementName(name string) string {
+	mapped := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.' {
+			return r
+		}
+		return '_'
+	}, name)
+	if mapped == "" {
+		return "_"
+	}
+	first := []rune(mapped)[0]
+	if (!unicode.IsLetter(first) && first != '_') || strings.HasPrefix(strings.ToLower(mapped), "xml") {
+		mapped = "_" + mapped
+	}
+	return mapped
+}
+
+// orderedKeys returns the keys of fields: those listed in names first, in that order,
+// then the rest sorted.
+func orderedKeys(fields map[string]interface{}, names []string) []string {
+	keys := make([]string, 0, len(fields))
+	listed := make(map[string]bool, len(names))
+	for _, name := range names {
+		if _, ok := fields[name]; ok && !listed[name] {
+			listed[name] = true
+			keys = append(keys, name)
+		}
+	}
+	var rest []string
+	for key := range fields {
+		if !listed[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
@@ -0,0 +1,222 @@
+// File: pkg/storage/xml_test.go
+
+package storage
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/heinrichb/scrapey-cli/pkg/parser"
+)
+
+// TestSaveDataXML verifies element naming, escaping, nesting and the metadata options.
+func TestSaveDataXML(t *testing.T) {
+	records := []*parser.Record{
+		{
+			URL: "https://example.com/a?x=1&y=2",
+			Fields: map[string]interface{}{
+				"title":           "Fish & <Chips>\x00",
+				"tags":            []interface{}{"a", "b"},
+				"price":           map[string]interface{}{"currency": "EUR", "amount": 9.5},
+				"Price / Monthly": "5",
+				"empty":           nil,
+			},
+			FetchedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+		},
+	}
+
+	cases := []struct {
+		desc       string
+		root, item string
+		attributes bool
+		expected   string
+	}{
+		{
+			desc: "Defaults",
+			expected: `<?xml version="1.0" encoding="UTF-8"?>
+<records>
+  <record>
+    <url>https://example.com/a?x=1&amp;y=2</url>
+    <fetchedAt>2025-03-01T12:00:00Z</fetchedAt>
+    <title>Fish &amp; &lt;Chips&gt;` + "�" + `</title>
+    <Price___Monthly name="Price / Monthly">5</Price___Monthly>
+    <price>
+      <amount>9.5</amount>
+      <currency>EUR</currency>
+    </price>
+    <tags>
+      <item>a</item>
+      <item>b</item>
+    </tags>
+  </record>
+</records>
+`,
+		},
+		{
+			desc:       "Custom names and metadata attributes",
+			root:       "products",
+			item:       "product",
+			attributes: true,
+			expected: `<?xml version="1.0" encoding="UTF-8"?>
+<products>
+  <product url="https://example.com/a?x=1&amp;y=2" fetchedAt="2025-03-01T12:00:00Z">
+    <title>Fish &amp; &lt;Chips&gt;` + "�" + `</title>
+    <Price___Monthly name="Price / Monthly">5</Price___Monthly>
+    <price>
+      <amount>9.5</amount>
+      <currency>EUR</currency>
+    </price>
+    <tags>
+      <item>a</item>
+      <item>b</item>
+    </tags>
+  </product>
+</products>
+`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cfg := testConfig(t.TempDir())
+			cfg.ParseRules.Title = "h1"
+			cfg.Storage.XML.RootElement = tc.root
+			cfg.Storage.XML.ItemElement = tc.item
+			cfg.Storage.XML.MetadataAttributes = tc.attributes
+
+			path, err := SaveData(records, cfg, XML)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !strings.HasSuffix(path, "results.xml") {
+				t.Errorf("Unexpected path %s", path)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			if string(data) != tc.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tc.expected, data)
+			}
+			if err := xml.Unmarshal(data, new(interface{})); err != nil {
+				t.Errorf("Output is not well-formed: %v", err)
+			}
+		})
+	}
+}
+
+// TestElementName verifies that field names are mapped to valid element names.
+func TestElementName(t *testing.T) {
+	cases := map[string]string{
+		"title":       "title",
+		"price.cost":  "price.cost",
+		"2nd":         "_2nd",
+		"xmlData":     "_xmlData",
+		"":            "_",
+		"Größe (cm)":  "Größe__cm_",
+		"-leading":    "_-leading",
+		"with space":  "with_space",
+		"colon:value": "colon_value",
+	}
+	for name, expected := range cases {
+		if got := elementName(name); got != expected {
+			t.Errorf("elementName(%q): expected %q, got %q", name, expected, got)
+		}
+	}
+}
+
+// TestSaveDataXMLEmpty verifies that a crawl without records yields an empty root element.
+func TestSaveDataXMLEmpty(t *testing.T) {
+	cfg := testConfig(t.TempDir())
+	path, err := SaveData(nil, cfg, XML)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	expected := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<records></records>\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+}
+
+// TestXMLSink verifies that streamed records leave the previous output alone until Close
+// and that the finished document matches SaveData's.
+func TestXMLSink(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	cfg := testConfig(dir)
+	path := filepath.Join(dir, "results.xml")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("previous run"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	sink, err := OpenXML(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if sink.Path() != path {
+		t.Errorf("Unexpected path %s", sink.Path())
+	}
+	for _, record := range testRecords() {
+		if err := sink.Write(record); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != "previous run" {
+		t.Errorf("Expected the previous output until Close, got %q", data)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	streamed, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+
+	if _, err := SaveData(testRecords(), cfg, XML); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	saved, _ := os.ReadFile(path)
+	if string(streamed) != string(saved) {
+		t.Errorf("Expected the streamed document to match SaveData's:\n%s\ngot:\n%s", saved, streamed)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only the output file, got %d entries", len(entries))
+	}
+}
+
+// TestXMLSinkDiscard verifies that a discarded document, as after a failed crawl, leaves
+// the previous output and no temporary file behind.
+func TestXMLSinkDiscard(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "results.xml")
+	if err := os.WriteFile(path, []byte("previous run"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	sink, err := OpenXML(testConfig(dir))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, record := range testRecords() {
+		if err := sink.Write(record); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	sink.Discard()
+
+	if data, _ := os.ReadFile(path); string(data) != "previous run" {
+		t.Errorf("Expected the previous output to survive, got %q", data)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only the output file, got %d entries", len(entries))
+	}
+}